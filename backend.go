@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Backend is a TUI library that renders the sample app: a list pane
+// at the left, an output pane at the right, and an input pane below
+// the output pane. The app logic itself lives in runBackend, so that
+// every library only has to provide the panes and an event loop.
+type Backend interface {
+	// Init initializes the library and takes over the terminal.
+	Init() error
+	// BuildList, BuildOutput, and BuildInput create the three panes.
+	BuildList() error
+	BuildOutput() error
+	BuildInput() error
+	// AppendOutput writes s to the output pane, and ClearOutput
+	// empties it.
+	AppendOutput(s string)
+	ClearOutput()
+	// SetListItems replaces the contents of the list pane.
+	SetListItems(items []string)
+	// OnSubmit sets the function that receives each line the user
+	// enters in the input pane.
+	OnSubmit(f func(line string))
+	// Run starts the event loop and blocks until the user quits, or
+	// until Quit is called from within the event loop.
+	Run() error
+	Quit()
+	// Close restores the terminal.
+	Close()
+}
+
+// backends maps the command line name of a TUI library to a
+// constructor for its Backend. Each backend registers itself from an
+// init func.
+var backends = map[string]func() Backend{}
+
+func registerBackend(name string, newBackend func() Backend) {
+	backends[name] = newBackend
+}
+
+// backendNames returns the names of all registered backends in
+// alphabetical order.
+func backendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commandNames returns the names of all registered commands in
+// alphabetical order.
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commands maps the command line name of a command that is not a
+// backend, like "evaluate", to the function that runs it with the
+// remaining arguments.
+var commands = map[string]func(args []string) error{}
+
+func registerCommand(name string, run func(args []string) error) {
+	commands[name] = run
+}
+
+// parseBackendFlags parses the options that may follow the name of a
+// backend on the command line.
+func parseBackendFlags(name string, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Func("taborder", "comma-separated `panes` that Tab cycles through (default \"list,output,input\")", parseTabOrder)
+	flags.BoolVar(&viMode, "vi", false, "edit the input with vi keys")
+	flags.StringVar(&historyPath, "history", defaultHistoryPath(), "`file` that keeps the input history, or \"\" for none")
+	flags.IntVar(&maxInputHeight, "maxinput", maxInputHeight, "maximum `height` of the input pane, in rows, as it grows with multi-line input")
+	flags.IntVar(&pasteConfirmLines, "pasteconfirm", 0, "ask before inserting a paste of more than this many `lines`, or 0 to never ask")
+	flags.Func("handler", fmt.Sprintf("`name` of the handler for the input: %s (default \"echo\")", strings.Join(inputHandlerNames(), ", ")), setInputHandler)
+	return flags.Parse(args)
+}
+
+// runBackend builds the sample app with b and runs it until the user
+// quits.
+func runBackend(b Backend) error {
+	err := b.Init()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	err = b.BuildList()
+	if err != nil {
+		return errors.Wrap(err, "Cannot build list pane")
+	}
+	err = b.BuildOutput()
+	if err != nil {
+		return errors.Wrap(err, "Cannot build output pane")
+	}
+	err = b.BuildInput()
+	if err != nil {
+		return errors.Wrap(err, "Cannot build input pane")
+	}
+
+	// The slash commands can change the list, so the app works on a
+	// copy of the items.
+	a := &app{b: b, items: append([]string(nil), listItems...)}
+	b.SetListItems(a.items)
+	b.AppendOutput("Press Ctrl-C to quit\n")
+
+	// Text entered in the input pane shall appear in the output pane,
+	// unless it is a command (see slash.go).
+	b.OnSubmit(a.submit)
+
+	return b.Run()
+}
+
+// A rect holds the corners of a pane's frame. Like the coordinates
+// that gocui's SetView expects, both corners are part of the frame.
+type rect struct {
+	x0, y0, x1, y1 int
+}
+
+// paneRects calculates the frames of the list, output, and input panes
+// for a terminal of the size tw x th. The list pane has the width and
+// the input pane the height that s holds, and the output pane gets
+// the rest.
+func (s paneSizes) paneRects(tw, th int) (list, output, input rect) {
+	w, h := s.size(tw, th)
+	list = rect{0, 0, w, th - 1}
+	output = rect{w + 1, 0, tw - 1, th - h - 1}
+	input = rect{w + 1, th - h, tw - 1, th - 1}
+	return list, output, input
+}
+
+// paneAt returns the visible pane at the screen position x, y, and the
+// row within the pane's interior, or -1 if y is on the pane's frame.
+// focus is the focused pane.
+func (s paneSizes) paneAt(x, y, tw, th int, focus pane) (p pane, row int, ok bool) {
+	for p, r := range s.frames(tw, th, focus) {
+		if x < r.x0 || x > r.x1 || y < r.y0 || y > r.y1 {
+			continue
+		}
+		row = y - r.y0 - 1
+		if x == r.x0 || x == r.x1 || y == r.y1 {
+			row = -1
+		}
+		return p, row, true
+	}
+	return 0, 0, false
+}
+
+// A pane identifies one of the three panes, for example the one that
+// has the keyboard focus. The zero value is the input pane, which has
+// the focus when the app starts.
+type pane int
+
+const (
+	paneInput pane = iota
+	paneList
+	paneOutput
+)
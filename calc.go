@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// The calc input handler evaluates arithmetic expressions like
+// "2 * (3 + 4) ^ 2". It knows + - * / % and ^ for powers, with the
+// usual precedence, parentheses, and signs. ^ binds to the right, like
+// in math.
+
+// calcInput writes the value of the expression line to out.
+func calcInput(line string, out io.Writer) error {
+	v, err := calc(line)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s = %s\n", line, strconv.FormatFloat(v, 'g', -1, 64))
+	return err
+}
+
+// A calcParser evaluates an expression while parsing it, by recursive
+// descent. pos is the index of the next rune in expr.
+type calcParser struct {
+	expr []rune
+	pos  int
+}
+
+// calc returns the value of the expression s.
+func calc(s string) (float64, error) {
+	p := &calcParser{expr: []rune(s)}
+	v, err := p.sum()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != 0 {
+		return 0, p.errorf("unexpected %q", p.peek())
+	}
+	return v, nil
+}
+
+// peek returns the next rune that is not blank, or 0 at the end.
+func (p *calcParser) peek() rune {
+	for p.pos < len(p.expr) && unicode.IsSpace(p.expr[p.pos]) {
+		p.pos++
+	}
+	if p.pos == len(p.expr) {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func (p *calcParser) errorf(format string, args ...any) error {
+	return errors.Errorf("calc: "+format+" at column %d", append(args, p.pos+1)...)
+}
+
+// sum parses terms separated by + or -.
+func (p *calcParser) sum() (float64, error) {
+	v, err := p.product()
+	for err == nil {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		var w float64
+		w, err = p.product()
+		if op == '+' {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, err
+}
+
+// product parses factors separated by *, /, or %.
+func (p *calcParser) product() (float64, error) {
+	v, err := p.power()
+	for err == nil {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			break
+		}
+		p.pos++
+		// at is where the divisor starts, for the error message.
+		p.peek()
+		at := p.pos
+		var w float64
+		w, err = p.power()
+		switch {
+		case err != nil:
+		case op != '*' && w == 0:
+			p.pos = at
+			err = p.errorf("division by zero")
+		case op == '*':
+			v *= w
+		case op == '/':
+			v /= w
+		default:
+			v = math.Mod(v, w)
+		}
+	}
+	return v, err
+}
+
+// power parses a signed operand, optionally raised to a power. The
+// sign binds less than ^, so that -2^2 is -4.
+func (p *calcParser) power() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.power()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.power()
+	}
+	v, err := p.operand()
+	if err != nil || p.peek() != '^' {
+		return v, err
+	}
+	p.pos++
+	w, err := p.power()
+	return math.Pow(v, w), err
+}
+
+// operand parses a number or an expression in parentheses.
+func (p *calcParser) operand() (float64, error) {
+	switch ch := p.peek(); {
+	case ch == '(':
+		p.pos++
+		v, err := p.sum()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, p.errorf("missing )")
+		}
+		p.pos++
+		return v, nil
+	case ch == '.' || unicode.IsDigit(ch):
+		start := p.pos
+		for p.pos < len(p.expr) && (p.expr[p.pos] == '.' || unicode.IsDigit(p.expr[p.pos])) {
+			p.pos++
+		}
+		// An exponent, like in 1.5e3.
+		if p.pos < len(p.expr) && (p.expr[p.pos] == 'e' || p.expr[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(p.expr) && (p.expr[p.pos] == '+' || p.expr[p.pos] == '-') {
+				p.pos++
+			}
+			for p.pos < len(p.expr) && unicode.IsDigit(p.expr[p.pos]) {
+				p.pos++
+			}
+		}
+		num := string(p.expr[start:p.pos])
+		v, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			p.pos = start
+			return 0, p.errorf("bad number %q", num)
+		}
+		return v, nil
+	case ch == 0:
+		return 0, p.errorf("unexpected end")
+	default:
+		return 0, p.errorf("unexpected %q", ch)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalc(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"10 - 4 - 3", 3},
+		{"7 % 4 / 2", 1.5},
+		{"1.5e3 + .5", 1500.5},
+		{"--3", 3},
+	}
+	for _, tt := range tests {
+		got, err := calc(tt.expr)
+		if err != nil || got != tt.want {
+			t.Errorf("calc(%q): got %v, %v, want %v", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestCalcErrors(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"1 +", "unexpected end at column 4"},
+		{"(1 + 2", "missing ) at column 7"},
+		{"1 / (2 - 2)", "division by zero at column 5"},
+		{"2 x", `unexpected 'x' at column 3`},
+		{"1..2", `bad number "1..2" at column 1`},
+		{"", "unexpected end at column 1"},
+	}
+	for _, tt := range tests {
+		_, err := calc(tt.expr)
+		if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+			t.Errorf("calc(%q): got %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// The low-level libraries know nothing about panes, frames, or edit
+// boxes. All they provide is a grid of character cells and a stream of
+// events. cellPanes draws the sample app into such a grid and handles
+// the keys, so that a low-level backend only needs to supply a canvas
+// and an event loop.
+
+// color is a library-independent color. Each backend maps it to its
+// own color type.
+type color int
+
+const (
+	colorDefault color = iota
+	colorBlack
+	colorRed
+	colorGreen
+	colorYellow
+	colorBlue
+	colorMagenta
+	colorCyan
+	colorWhite
+)
+
+// A style describes how a cell is drawn.
+type style struct {
+	fg, bg  color
+	reverse bool
+}
+
+// A canvas is a grid of character cells. Cells outside the grid are
+// silently ignored.
+type canvas interface {
+	Size() (w, h int)
+	Clear()
+	SetCell(x, y int, ch rune, st style)
+	ShowCursor(x, y int)
+	HideCursor()
+}
+
+// cellPanes is the state of the sample app for the low-level backends.
+// It implements all the pane-related methods of Backend.
+type cellPanes struct {
+	list     listModel
+	output   outputModel
+	input    lineEditor
+	history  inputHistory
+	complete completion
+	paste    pasteGuard
+	onSubmit func(string)
+	focus    focusRing
+	sizes    paneSizes
+	// quit is set by Quit, which a slash command calls while
+	// handleKey is running.
+	quit bool
+}
+
+// The panes are drawn from scratch on every redraw, so there is
+// nothing to build upfront, except for loading the input history.
+func (p *cellPanes) BuildList() error   { return nil }
+func (p *cellPanes) BuildOutput() error { return nil }
+
+func (p *cellPanes) BuildInput() error {
+	p.input.vi = viMode
+	// Without its history, the input pane still works, so a broken
+	// history file is no reason to fail.
+	if err := p.history.Load(historyPath); err != nil {
+		log.Println(err)
+	}
+	return nil
+}
+
+func (p *cellPanes) AppendOutput(s string) {
+	p.output.Append(s)
+}
+
+func (p *cellPanes) ClearOutput() {
+	p.output.Clear()
+}
+
+// Quit makes handleKey report that the user wants to quit.
+func (p *cellPanes) Quit() {
+	p.quit = true
+}
+
+func (p *cellPanes) SetListItems(items []string) {
+	p.list.SetItems(items)
+}
+
+func (p *cellPanes) OnSubmit(f func(string)) {
+	p.onSubmit = f
+}
+
+// handleKey applies a key event on a screen of size tw x th to the app
+// and reports whether the user wants to quit. Keys that are not global
+// go to the focused pane only.
+func (p *cellPanes) handleKey(ev keyEvent, tw, th int) (quit bool) {
+	switch {
+	case ev == ctrl('c'):
+		return true
+	case p.paste.HandleKey(ev, &p.input):
+		p.focus.Set(paneInput)
+		p.history.searching, p.complete.active = false, false
+	case p.focus.Pane() == paneInput && !p.history.searching && p.complete.HandleKey(ev, &p.input, p.list.items):
+	case ev.key == keyTab:
+		p.focus.Next()
+	case ev.key == keyBacktab:
+		p.focus.Prev()
+	case ev == zoomKey:
+		p.sizes.ToggleZoom()
+	case p.resizeKey(ev, tw, th):
+	case p.focus.Pane() == paneList:
+		p.handleListKey(ev)
+	case p.focus.Pane() == paneOutput:
+		p.output.HandleKey(ev)
+	case p.history.HandleKey(ev, &p.input):
+	case p.input.submitKey(ev):
+		line := p.input.String()
+		p.input.Reset()
+		err := p.history.Add(line)
+		if err != nil {
+			log.Println(err)
+		}
+		if p.onSubmit != nil {
+			p.onSubmit(line)
+		}
+	default:
+		p.input.HandleKey(ev)
+	}
+	return p.quit
+}
+
+// resizeKey resizes the panes if ev is one of the resizeKeys, and
+// reports whether it is.
+func (p *cellPanes) resizeKey(ev keyEvent, tw, th int) bool {
+	d, ok := resizeKeys[ev]
+	if ok {
+		p.sizes.Resize(d.dw, d.dh, tw, th)
+	}
+	return ok
+}
+
+// handleListKey moves the selection in the list, or writes the selected
+// item to the output on Enter.
+func (p *cellPanes) handleListKey(ev keyEvent) {
+	if ev.key != keyEnter {
+		p.list.HandleKey(ev)
+		return
+	}
+	if item, ok := p.list.Selected(); ok {
+		p.AppendOutput(item + "\n")
+	}
+}
+
+// handleMouse applies a mouse event on a screen of size tw x th to the
+// app. A click focuses the pane under the pointer and selects the list
+// item there, and the wheel scrolls the output pane. Dragging a border
+// between the panes resizes them.
+func (p *cellPanes) handleMouse(ev mouseEvent, tw, th int) {
+	switch {
+	case ev.button == mouseLeft && ev.motion:
+		p.sizes.Drag(ev.x, ev.y, tw, th)
+		return
+	case ev.button == mouseRelease:
+		p.sizes.EndDrag()
+		return
+	case ev.button == mouseLeft && p.sizes.StartDrag(ev.x, ev.y, tw, th):
+		return
+	}
+	pn, row, ok := p.sizes.paneAt(ev.x, ev.y, tw, th, p.focus.Pane())
+	if !ok {
+		return
+	}
+	switch {
+	case ev.button == mouseLeft && !ev.motion:
+		p.focus.Set(pn)
+		if pn == paneList {
+			p.list.SelectVisible(row)
+		}
+	case ev.button == mouseWheelUp && pn == paneOutput:
+		p.output.Scroll(wheelLines)
+	case ev.button == mouseWheelDown && pn == paneOutput:
+		p.output.Scroll(-wheelLines)
+	}
+}
+
+// draw renders the visible panes onto cv, using the same coordinates
+// as the gocui layout.
+func (p *cellPanes) draw(cv canvas) {
+	cv.Clear()
+	tw, th := cv.Size()
+	focus := p.focus.Pane()
+	p.sizes.inputLines = p.input.Lines()
+	frames := p.sizes.frames(tw, th, focus)
+	theme := currentTheme()
+	text := style{fg: theme.text}
+
+	if lr, ok := frames[paneList]; ok {
+		items, sel := p.list.Visible(lr.y1 - lr.y0 - 1)
+		drawFrame(cv, lr, p.list.Title(), theme.list, focus == paneList)
+		drawLines(cv, lr, items, text)
+		if sel >= 0 {
+			// Pad the selected item so that the highlight spans
+			// the whole row.
+			row := items[sel] + strings.Repeat(" ", lr.x1-lr.x0)
+			drawLines(cv, rect{lr.x0, lr.y0 + sel, lr.x1, lr.y1}, []string{row}, selectedStyle(focus == paneList))
+		}
+		for i, item := range items {
+			spans := p.list.Spans(i)
+			if i == sel {
+				spans = selectedSpans(spans, focus == paneList)
+			}
+			drawSpans(cv, lr, i, item, spans)
+		}
+	}
+
+	// Unless scrolled back, the output pane shows the most recent
+	// lines. The title depends on the visible part, so the lines go
+	// first.
+	if or, ok := frames[paneOutput]; ok {
+		lines := p.output.Visible(or.y1 - or.y0 - 1)
+		drawFrame(cv, or, p.output.Title(), theme.output, focus == paneOutput)
+		drawLines(cv, or, lines, text)
+		for i, line := range lines {
+			drawSpans(cv, or, i, line, p.output.Spans(i))
+		}
+	}
+
+	// The input pane scrolls to keep the cursor visible.
+	ir, ok := frames[paneInput]
+	if !ok {
+		cv.HideCursor()
+		return
+	}
+	w := ir.x1 - ir.x0 - 1
+	lines, cx, cy := p.input.Visible(w, ir.y1-ir.y0-1)
+	drawFrame(cv, ir, inputTitle(&p.input, &p.history, &p.paste), theme.input, focus == paneInput)
+	rows := make([]string, len(lines))
+	for i, line := range lines {
+		rows[i] = string(line)
+	}
+	drawLines(cv, ir, rows, text)
+	p.drawPopup(cv, ir)
+	if w > 0 && focus == paneInput {
+		cv.ShowCursor(ir.x0+1+cx, ir.y0+1+cy)
+	} else {
+		cv.HideCursor()
+	}
+}
+
+// drawPopup draws the popup with the completion candidates over the
+// panes above the input pane with the frame ir.
+func (p *cellPanes) drawPopup(cv canvas, ir rect) {
+	r, cands, sel, ok := p.complete.Popup(ir, &p.input)
+	if !ok {
+		return
+	}
+	for y := r.y0; y <= r.y1; y++ {
+		for x := r.x0; x <= r.x1; x++ {
+			cv.SetCell(x, y, ' ', style{})
+		}
+	}
+	drawFrame(cv, r, "", colorDefault, true)
+	drawLines(cv, r, cands, style{fg: currentTheme().text})
+	if sel >= 0 {
+		row := cands[sel] + strings.Repeat(" ", r.x1-r.x0)
+		drawLines(cv, rect{r.x0, r.y0 + sel, r.x1, r.y1}, []string{row}, selectedStyle(true))
+	}
+}
+
+// selectedStyle returns the style of the selected list item. The
+// selection stands out in reverse video while the list has the focus.
+func selectedStyle(focused bool) style {
+	if focused {
+		return style{reverse: true}
+	}
+	return style{fg: colorYellow}
+}
+
+// matchStyle returns the style of a search match in the output pane.
+// The current match stands out from the others.
+func matchStyle(current bool) style {
+	if current {
+		return style{fg: colorBlack, bg: colorCyan}
+	}
+	return style{fg: colorBlack, bg: colorYellow}
+}
+
+// fuzzyStyle is the style of the characters of a list item that match
+// the query.
+var fuzzyStyle = style{fg: colorRed}
+
+// selectedSpans carries the reverse video of the selected list item
+// over to its highlighted characters.
+func selectedSpans(spans []span, focused bool) []span {
+	for i := range spans {
+		spans[i].st.reverse = spans[i].st.reverse || selectedStyle(focused).reverse
+	}
+	return spans
+}
+
+// borderStyle returns the style of a pane's border. The border of the
+// focused pane is highlighted in the theme's focus color.
+func borderStyle(focused bool) style {
+	if focused {
+		return style{fg: currentTheme().focus}
+	}
+	return style{}
+}
+
+// drawFrame draws the border of r with the title in its top edge, in
+// color c.
+func drawFrame(cv canvas, r rect, title string, c color, focused bool) {
+	if r.x1 <= r.x0 || r.y1 <= r.y0 {
+		return
+	}
+	st := borderStyle(focused)
+	for x := r.x0 + 1; x < r.x1; x++ {
+		cv.SetCell(x, r.y0, '─', st)
+		cv.SetCell(x, r.y1, '─', st)
+	}
+	for y := r.y0 + 1; y < r.y1; y++ {
+		cv.SetCell(r.x0, y, '│', st)
+		cv.SetCell(r.x1, y, '│', st)
+	}
+	cv.SetCell(r.x0, r.y0, '┌', st)
+	cv.SetCell(r.x1, r.y0, '┐', st)
+	cv.SetCell(r.x0, r.y1, '└', st)
+	cv.SetCell(r.x1, r.y1, '┘', st)
+
+	x := r.x0 + 2
+	for _, ch := range title {
+		if x >= r.x1 {
+			break
+		}
+		cv.SetCell(x, r.y0, ch, style{fg: c})
+		x++
+	}
+}
+
+// A span is a part of a line, in runes, that is drawn in style st,
+// like a search match.
+type span struct {
+	start, end int
+	st         style
+}
+
+// drawSpans redraws the spans of a line that drawLines wrote into row
+// i of r.
+func drawSpans(cv canvas, r rect, i int, line string, spans []span) {
+	text := []rune(line)
+	for _, sp := range spans {
+		for j := sp.start; j < sp.end && j < len(text); j++ {
+			if x := r.x0 + 1 + j; x < r.x1 {
+				cv.SetCell(x, r.y0+1+i, text[j], sp.st)
+			}
+		}
+	}
+}
+
+// drawLines writes lines into the interior of r, clipping whatever
+// does not fit.
+func drawLines(cv canvas, r rect, lines []string, st style) {
+	for i, line := range lines {
+		y := r.y0 + 1 + i
+		if y >= r.y1 {
+			return
+		}
+		x := r.x0 + 1
+		for _, ch := range line {
+			if x >= r.x1 {
+				break
+			}
+			cv.SetCell(x, y, ch, st)
+			x++
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tab completes the word left of the cursor in the input pane. At the
+// start of the line, a word with a slash completes to a slash command,
+// and the arguments of some commands complete to what the command
+// expects. A word that looks like a path completes to a file, and any
+// other word to a list item or a file in the current directory. If more
+// than one candidate fits, Tab completes as far as they agree and shows
+// them in a popup above the input pane. More Tabs then cycle through
+// them, and Shift-Tab cycles back. Enter keeps the current candidate,
+// Esc or Ctrl-G go back to the typed word, and any other key keeps the
+// candidate and closes the popup. Without a word to complete, Tab moves
+// the focus, like in the other panes.
+
+// popupLines is the largest number of candidates that the popup shows
+// at once. It scrolls to show the others.
+const popupLines = 8
+
+// A completion is the state of completing a word in the input pane.
+type completion struct {
+	// active is set while the popup shows. start and end delimit the
+	// completed part of the line, and typed is the word that the user
+	// typed, before Tab completed the common part of the candidates.
+	active     bool
+	start, end int
+	typed      string
+	// menu holds the candidates of the popup. cycling is set once Tab
+	// has put one of them into the line.
+	menu    listModel
+	cycling bool
+}
+
+// HandleKey completes the word left of the cursor of e, or cycles
+// through the candidates, and reports whether ev was a completion key.
+// items are the list items to complete.
+func (c *completion) HandleKey(ev keyEvent, e *lineEditor, items []string) bool {
+	if c.active {
+		switch {
+		case ev.key == keyTab:
+			c.cycle(e, 1)
+			return true
+		case ev.key == keyBacktab:
+			c.cycle(e, -1)
+			return true
+		case ev.key == keyEnter:
+			c.active = false
+			return true
+		case stopKeys[ev]:
+			c.active = false
+			e.replace(c.start, c.end, c.typed)
+			return true
+		}
+		c.active = false
+		return false
+	}
+	if ev.key != keyTab || ev.mod != 0 || e.normal {
+		return false
+	}
+	start := e.blankWordLeft()
+	if start == e.cursor || wordClass(e.text[e.cursor-1]) == 0 {
+		return false
+	}
+	word := string(e.text[start:e.cursor])
+	cands := completions(string(e.text[:start]), word, items)
+	if len(cands) == 0 {
+		return true
+	}
+	e.checkpoint()
+	e.last = opOther
+	if len(cands) == 1 {
+		s := cands[0]
+		if !strings.HasSuffix(s, "/") {
+			s += " "
+		}
+		e.replace(start, e.cursor, s)
+		return true
+	}
+	// Complete the common part of the candidates, and show them all.
+	e.replace(start, e.cursor, commonPrefix(word, cands))
+	*c = completion{active: true, start: start, end: e.cursor, typed: word}
+	c.menu.SetItems(cands)
+	return true
+}
+
+// cycle puts the next candidate into the line, or the previous one if
+// dir is negative.
+func (c *completion) cycle(e *lineEditor, dir int) {
+	n := c.menu.count()
+	i := c.menu.selected
+	switch {
+	case c.cycling:
+		i = (i + dir + n) % n
+	case dir < 0:
+		i = n - 1
+	default:
+		i = 0
+	}
+	c.cycling = true
+	c.menu.Select(i)
+	s := c.menu.item(i)
+	e.replace(c.start, c.end, s)
+	c.end = c.start + utf8.RuneCountInString(s)
+}
+
+// Popup returns the frame of the popup for an input pane with the
+// frame ir and the line editor e, the candidates that fit into the
+// popup, and the index of the current one, or -1 before cycling. The
+// popup lies above the input pane, and its candidates start below the
+// completed word. ok is false if the popup is closed or there is no
+// room for it.
+func (c *completion) Popup(ir rect, e *lineEditor) (r rect, cands []string, sel int, ok bool) {
+	if !c.active {
+		return r, nil, -1, false
+	}
+	w := 0
+	for _, s := range c.menu.items {
+		w = max(w, utf8.RuneCountInString(s))
+	}
+	r.y1 = ir.y0 - 1
+	r.y0 = max(0, r.y1-min(c.menu.count(), popupLines)-1)
+	_, col := e.position(c.start)
+	r.x0 = max(ir.x0, ir.x0+col-e.off)
+	r.x1 = r.x0 + w + 1
+	if r.x1 > ir.x1 {
+		r.x0 = max(ir.x0, r.x0-(r.x1-ir.x1))
+		r.x1 = ir.x1
+	}
+	if r.y1-r.y0 < 2 || r.x1-r.x0 < 2 {
+		return r, nil, -1, false
+	}
+	cands, sel = c.menu.Visible(r.y1 - r.y0 - 1)
+	if !c.cycling {
+		sel = -1
+	}
+	return r, cands, sel, true
+}
+
+// completions returns the candidates for word, given the part of the
+// line before it.
+func completions(before, word string, items []string) []string {
+	switch {
+	case strings.HasPrefix(word, "/") && strings.TrimSpace(before) == "":
+		if cands := commandCandidates(word); len(cands) > 0 {
+			return cands
+		}
+		return pathCandidates(word)
+	case strings.ContainsRune(word, '/'), strings.HasPrefix(word, "."), strings.HasPrefix(word, "~"):
+		return pathCandidates(word)
+	}
+	if f := strings.Fields(before); len(f) > 0 && strings.HasPrefix(f[0], "/") {
+		if cmd, ok := slashCommands[f[0][1:]]; ok && cmd.complete != nil {
+			return itemCandidates(word, cmd.complete())
+		}
+	}
+	cands := itemCandidates(word, items)
+	seen := map[string]bool{}
+	for _, s := range cands {
+		seen[s] = true
+	}
+	for _, s := range pathCandidates(word) {
+		if !seen[s] {
+			cands = append(cands, s)
+		}
+	}
+	return cands
+}
+
+// commandCandidates returns the slash commands that start with word.
+func commandCandidates(word string) []string {
+	var cands []string
+	for _, name := range slashCommandNames() {
+		if strings.HasPrefix("/"+name, word) {
+			cands = append(cands, "/"+name)
+		}
+	}
+	return cands
+}
+
+// itemCandidates returns the items that start with word, ignoring case.
+func itemCandidates(word string, items []string) []string {
+	var cands []string
+	for _, item := range items {
+		if strings.HasPrefix(strings.ToLower(item), strings.ToLower(word)) {
+			cands = append(cands, item)
+		}
+	}
+	return cands
+}
+
+// pathCandidates returns the files whose paths start with word. The
+// names of directories end with a slash. Hidden files only show up if
+// word asks for them, and a leading ~/ stands for the home directory.
+func pathCandidates(word string) []string {
+	dir, base := filepath.Split(word)
+	read := dir
+	if read == "" {
+		read = "."
+	}
+	if strings.HasPrefix(read, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		read = filepath.Join(home, read[2:])
+	}
+	entries, err := os.ReadDir(read)
+	if err != nil {
+		return nil
+	}
+	var cands []string
+	for _, en := range entries {
+		name := en.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if en.IsDir() {
+			name += "/"
+		}
+		cands = append(cands, dir+name)
+	}
+	return cands
+}
+
+// commonPrefix returns the longest prefix of all candidates, which all
+// start with word, ignoring case. Where the candidates differ in case,
+// the prefix keeps word's spelling.
+func commonPrefix(word string, cands []string) string {
+	prefix := []rune(cands[0])
+	exact := len(prefix)
+	for _, s := range cands[1:] {
+		r := []rune(s)
+		n := 0
+		for n < len(prefix) && n < len(r) && strings.EqualFold(string(prefix[n]), string(r[n])) {
+			n++
+		}
+		prefix = prefix[:n]
+		e := 0
+		for e < n && prefix[e] == r[e] {
+			e++
+		}
+		exact = min(exact, e)
+	}
+	w := []rune(word)
+	switch {
+	case len(prefix) <= len(w):
+		return word
+	case exact >= len(prefix):
+		return string(prefix)
+	}
+	return string(w) + string(prefix[len(w):])
+}
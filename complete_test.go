@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	// The current directory holds no files that could complete.
+	t.Chdir(t.TempDir())
+	items := []string{"Line 1", "Line 2", "Other"}
+	tab, backtab := keyEvent{key: keyTab}, keyEvent{key: keyBacktab}
+	var e lineEditor
+	var c completion
+	e.Set("say li")
+	if !c.HandleKey(tab, &e, items) || e.String() != "say Line " || !c.active {
+		t.Fatalf("Tab: got %q, active %v", e.String(), c.active)
+	}
+	if _, _, sel, _ := c.Popup(rect{0, 10, 40, 12}, &e); sel != -1 {
+		t.Errorf("popup before cycling: selected %d", sel)
+	}
+	c.HandleKey(tab, &e, items)
+	c.HandleKey(tab, &e, items)
+	if e.String() != "say Line 2" {
+		t.Errorf("cycling: got %q", e.String())
+	}
+	c.HandleKey(tab, &e, items)
+	c.HandleKey(backtab, &e, items)
+	if e.String() != "say Line 2" {
+		t.Errorf("cycling back: got %q", e.String())
+	}
+	// Ctrl-G goes back to the typed word, and so does Undo.
+	c.HandleKey(ctrl('g'), &e, items)
+	if e.String() != "say li" || c.active {
+		t.Errorf("Ctrl-G: got %q, active %v", e.String(), c.active)
+	}
+	e.Undo()
+	if e.String() != "say li" {
+		t.Errorf("undo: got %q", e.String())
+	}
+
+	// Enter keeps the candidate, and another key closes the popup
+	// without taking the key.
+	c.HandleKey(tab, &e, items)
+	c.HandleKey(tab, &e, items)
+	if !c.HandleKey(keyEvent{key: keyEnter}, &e, items) || e.String() != "say Line 1" || c.active {
+		t.Errorf("Enter: got %q, active %v", e.String(), c.active)
+	}
+	c.HandleKey(tab, &e, items)
+	if e.String() != "say Line 1" {
+		t.Errorf("Tab after a blank: got %q", e.String())
+	}
+
+	// A single candidate completes with a blank after it.
+	e.Set("o")
+	c.HandleKey(tab, &e, items)
+	if e.String() != "Other " || c.active {
+		t.Errorf("single candidate: got %q, active %v", e.String(), c.active)
+	}
+	if c.HandleKey(tab, &e, items) {
+		t.Error("Tab without a word was taken")
+	}
+	e.Set("")
+	if c.HandleKey(tab, &e, items) {
+		t.Error("Tab in an empty line was taken")
+	}
+}
+
+func TestCompletionPopup(t *testing.T) {
+	t.Chdir(t.TempDir())
+	var e lineEditor
+	var c completion
+	e.Set("x li")
+	c.HandleKey(keyEvent{key: keyTab}, &e, []string{"Line 1", "Line 2", "Line 3"})
+	c.HandleKey(keyEvent{key: keyTab}, &e, nil)
+	ir := rect{20, 20, 60, 22}
+	r, cands, sel, ok := c.Popup(ir, &e)
+	// The candidates start below the word, and the popup sits on top
+	// of the input pane.
+	if want := (rect{22, 15, 29, 19}); !ok || r != want {
+		t.Errorf("popup: got %v, %v, want %v", r, ok, want)
+	}
+	if len(cands) != 3 || sel != 0 {
+		t.Errorf("candidates: got %q, %d", cands, sel)
+	}
+	// Without room above the input pane, there is no popup.
+	if _, _, _, ok := c.Popup(rect{20, 1, 60, 3}, &e); ok {
+		t.Error("popup without room")
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "mod.go", ".hidden", "sub/x.go"} {
+		p := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(p), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+	registerSlashCommand("testcmd", slashCommand{})
+	t.Cleanup(func() { delete(slashCommands, "testcmd") })
+
+	// The Kelvin sign is longer in UTF-8 than the K it folds to.
+	items := []string{"Line 1", "mouse", "\u212aelvin"}
+	tests := []struct {
+		before, word string
+		want         []string
+	}{
+		{"", "/testc", []string{"/testcmd"}},
+		// Commands only come at the start of a line.
+		{"x ", "/testc", nil},
+		// Some commands complete their arguments.
+		{"/theme ", "oc", []string{"ocean"}},
+		{"", "m", []string{"mouse", "main.go", "mod.go"}},
+		{"", "s", []string{"sub/"}},
+		{"", "sub/", []string{"sub/x.go"}},
+		{"", "./m", []string{"./main.go", "./mod.go"}},
+		{"", ".h", []string{".hidden"}},
+		{"", "LI", []string{"Line 1"}},
+		{"", "ke", []string{"\u212aelvin"}},
+	}
+	for _, tt := range tests {
+		got := completions(tt.before, tt.word, items)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completions(%q, %q): got %q, want %q", tt.before, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		word  string
+		cands []string
+		want  string
+	}{
+		{"li", []string{"Line 1", "Line 2"}, "Line "},
+		{"li", []string{"Line 1", "line 2"}, "line "},
+		{"m", []string{"main.go", "mod.go"}, "m"},
+	}
+	for _, tt := range tests {
+		if got := commonPrefix(tt.word, tt.cands); got != tt.want {
+			t.Errorf("commonPrefix(%q, %q): got %q, want %q", tt.word, tt.cands, got, tt.want)
+		}
+	}
+}
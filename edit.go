@@ -0,0 +1,541 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A lineEditor holds the content of the input pane and the position of
+// the cursor within it. It edits the line with the keys of readline's
+// emacs mode, the default of most shells, or optionally with those of
+// vi (see vi.go). Alt-Enter or Shift-Enter insert a newline, which
+// turns the line into a multi-line input. submitKey tells which keys
+// submit the input.
+type lineEditor struct {
+	text   []rune
+	cursor int
+	// off is the first column and top the first line that are
+	// visible in the input pane.
+	off, top int
+	// vi turns on vi mode. normal is set in vi's normal mode, and
+	// pending holds a vi command that waits for another key, like d
+	// or r.
+	vi, normal bool
+	pending    rune
+	// meta is set after an Esc in emacs mode. Like in readline, Esc
+	// followed by a key counts as the key with Alt, which helps in
+	// terminals that send Alt that way.
+	meta bool
+	// kills is the kill ring, newest last, and yanked is the part of
+	// the line that the last yank inserted, so that Alt-Y can replace
+	// it with an older kill.
+	kills  []string
+	yanked struct{ start, end, kill int }
+	// undo and redo hold the states of the line before the last
+	// changes and after the last undos. last is the kind of the last
+	// command, which decides whether a change continues the previous
+	// one.
+	undo, redo []editState
+	last       editOp
+}
+
+// An editState is a snapshot of a line and its cursor.
+type editState struct {
+	text   []rune
+	cursor int
+}
+
+// An editOp is the kind of an editing command. Inserting characters
+// one after another is a single change for undo, and consecutive kills
+// go into a single entry of the kill ring.
+type editOp int
+
+const (
+	opOther editOp = iota
+	opInsert
+	opKill
+	opYank
+)
+
+// The limits of the kill ring and of the undo history.
+const (
+	killRingSize = 16
+	undoSize     = 100
+)
+
+// undoKey and redoKey take back the last change, and the last undo.
+// Terminals send the same code for Ctrl-/ and Ctrl-_, readline's undo
+// key.
+var (
+	undoKey = ctrl('/')
+	redoKey = keyEvent{key: keyRune, ch: '/', mod: modAlt}
+)
+
+// String returns the current content.
+func (e *lineEditor) String() string {
+	return string(e.text)
+}
+
+// Reset clears the content and the undo history, moves the cursor to
+// the start, and returns to vi's insert mode. The kill ring stays.
+func (e *lineEditor) Reset() {
+	e.text = e.text[:0]
+	e.cursor = 0
+	e.normal, e.pending, e.meta = false, 0, false
+	e.undo, e.redo = nil, nil
+	e.last = opOther
+}
+
+// Set replaces the content with s and moves the cursor to the end. The
+// undo history starts anew.
+func (e *lineEditor) Set(s string) {
+	e.text = []rune(s)
+	e.cursor = len(e.text)
+	e.undo, e.redo = nil, nil
+	e.last = opOther
+	e.fixCursor()
+}
+
+// Insert inserts ch at the cursor position and advances the cursor.
+func (e *lineEditor) Insert(ch rune) {
+	e.text = append(e.text, 0)
+	copy(e.text[e.cursor+1:], e.text[e.cursor:])
+	e.text[e.cursor] = ch
+	e.cursor++
+}
+
+// insertString inserts s at the cursor position and advances the
+// cursor.
+func (e *lineEditor) insertString(s string) {
+	r := []rune(s)
+	e.text = append(e.text[:e.cursor], append(r, e.text[e.cursor:]...)...)
+	e.cursor += len(r)
+}
+
+// Paste inserts the pasted text s at the cursor, as a single change.
+// The panes cannot show tabs, so they become four blanks.
+func (e *lineEditor) Paste(s string) {
+	if s == "" {
+		return
+	}
+	e.checkpoint()
+	e.insertString(strings.ReplaceAll(s, "\t", "    "))
+	e.meta, e.last = false, opOther
+	e.fixCursor()
+}
+
+// replace replaces the characters from index from up to, but not
+// including, index to with s, and puts the cursor after s.
+func (e *lineEditor) replace(from, to int, s string) {
+	r := []rune(s)
+	e.text = append(e.text[:from:from], append(r, e.text[to:]...)...)
+	e.cursor = from + len(r)
+}
+
+// Backspace deletes the character left of the cursor.
+func (e *lineEditor) Backspace() {
+	if e.cursor == 0 {
+		return
+	}
+	e.cursor--
+	e.Delete()
+}
+
+// Delete deletes the character under the cursor.
+func (e *lineEditor) Delete() {
+	if e.cursor >= len(e.text) {
+		return
+	}
+	e.text = append(e.text[:e.cursor], e.text[e.cursor+1:]...)
+}
+
+// Left and Right move the cursor by one character; Home and End move
+// it to the start or the end of the line.
+func (e *lineEditor) Left() {
+	if e.cursor > 0 {
+		e.cursor--
+	}
+}
+
+func (e *lineEditor) Right() {
+	if e.cursor < len(e.text) {
+		e.cursor++
+	}
+}
+
+func (e *lineEditor) Home() {
+	e.cursor = e.lineStart()
+}
+
+func (e *lineEditor) End() {
+	e.cursor = e.lineEnd()
+}
+
+// Click moves the cursor to column x of line y of the visible part of
+// the input.
+func (e *lineEditor) Click(x, y int) {
+	e.moveTo(e.top+y, e.off+x)
+	e.last = opOther
+	e.fixCursor()
+}
+
+// lines splits the input into its lines. They share the memory of the
+// text.
+func (e *lineEditor) lines() [][]rune {
+	var lines [][]rune
+	start := 0
+	for i, ch := range e.text {
+		if ch == '\n' {
+			lines = append(lines, e.text[start:i])
+			start = i + 1
+		}
+	}
+	return append(lines, e.text[start:])
+}
+
+// Lines returns the number of lines of the input.
+func (e *lineEditor) Lines() int {
+	n := 1
+	for _, ch := range e.text {
+		if ch == '\n' {
+			n++
+		}
+	}
+	return n
+}
+
+// lineStart returns the index of the first character of the line with
+// the cursor, and lineEnd the index of the newline that ends it, or
+// the length of the text on the last line.
+func (e *lineEditor) lineStart() int {
+	i := e.cursor
+	for i > 0 && e.text[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+func (e *lineEditor) lineEnd() int {
+	i := e.cursor
+	for i < len(e.text) && e.text[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+// position returns the line and the column of index i.
+func (e *lineEditor) position(i int) (row, col int) {
+	for _, ch := range e.text[:i] {
+		col++
+		if ch == '\n' {
+			row, col = row+1, 0
+		}
+	}
+	return row, col
+}
+
+// moveTo moves the cursor to column col of line row, or to the end of
+// that line if it is shorter.
+func (e *lineEditor) moveTo(row, col int) {
+	i := 0
+	for ; row > 0 && i < len(e.text); i++ {
+		if e.text[i] == '\n' {
+			row--
+		}
+	}
+	for ; col > 0 && i < len(e.text) && e.text[i] != '\n'; i++ {
+		col--
+	}
+	e.cursor = i
+}
+
+// lineUp and lineDown move the cursor to the same column of the
+// previous or the next line, and report whether there is such a line.
+func (e *lineEditor) lineUp() bool {
+	row, col := e.position(e.cursor)
+	if row == 0 {
+		return false
+	}
+	e.moveTo(row-1, col)
+	e.fixCursor()
+	return true
+}
+
+func (e *lineEditor) lineDown() bool {
+	row, col := e.position(e.cursor)
+	if row == e.Lines()-1 {
+		return false
+	}
+	e.moveTo(row+1, col)
+	e.fixCursor()
+	return true
+}
+
+// submitKey reports whether ev submits the input: Enter, Ctrl-Enter,
+// or Ctrl-J, which many terminals send for Ctrl-Enter. Enter with Alt
+// or Shift inserts a newline instead. An Esc before Enter does not
+// count as Alt, so that Enter never gets stuck.
+func (e *lineEditor) submitKey(ev keyEvent) bool {
+	return ev == ctrl('j') || ev.key == keyEnter && ev.mod&(modAlt|modShift) == 0
+}
+
+// wordClass tells apart blanks (0), word characters (1), and other
+// characters (2).
+func wordClass(ch rune) int {
+	switch {
+	case unicode.IsSpace(ch):
+		return 0
+	case ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch):
+		return 1
+	}
+	return 2
+}
+
+// wordLeft returns the start of the word left of the cursor, and
+// wordRight the end of the word right of it. Like in emacs, words
+// consist of letters and digits only.
+func (e *lineEditor) wordLeft() int {
+	i := e.cursor
+	for i > 0 && wordClass(e.text[i-1]) != 1 {
+		i--
+	}
+	for i > 0 && wordClass(e.text[i-1]) == 1 {
+		i--
+	}
+	return i
+}
+
+func (e *lineEditor) wordRight() int {
+	i := e.cursor
+	for i < len(e.text) && wordClass(e.text[i]) != 1 {
+		i++
+	}
+	for i < len(e.text) && wordClass(e.text[i]) == 1 {
+		i++
+	}
+	return i
+}
+
+// blankWordLeft returns the start of the word left of the cursor,
+// where a word is anything between blanks, like in a shell command.
+func (e *lineEditor) blankWordLeft() int {
+	i := e.cursor
+	for i > 0 && wordClass(e.text[i-1]) == 0 {
+		i--
+	}
+	for i > 0 && wordClass(e.text[i-1]) != 0 {
+		i--
+	}
+	return i
+}
+
+// checkpoint saves the line for undo before a change.
+func (e *lineEditor) checkpoint() {
+	e.undo = append(e.undo, editState{append([]rune(nil), e.text...), e.cursor})
+	if len(e.undo) > undoSize {
+		e.undo = e.undo[1:]
+	}
+	e.redo = nil
+}
+
+// Undo restores the line as it was before the last change.
+func (e *lineEditor) Undo() {
+	if len(e.undo) == 0 {
+		return
+	}
+	e.redo = append(e.redo, editState{e.text, e.cursor})
+	s := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	e.text, e.cursor = s.text, s.cursor
+	e.fixCursor()
+}
+
+// Redo takes back the last Undo.
+func (e *lineEditor) Redo() {
+	if len(e.redo) == 0 {
+		return
+	}
+	e.undo = append(e.undo, editState{e.text, e.cursor})
+	s := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	e.text, e.cursor = s.text, s.cursor
+	e.fixCursor()
+}
+
+// kill deletes the characters from index from up to, but not
+// including, index to and puts them into the kill ring. If the previous
+// command was a kill, too, the characters join the previous kill.
+func (e *lineEditor) kill(from, to int, prev editOp) {
+	e.last = opKill
+	if from >= to {
+		return
+	}
+	e.checkpoint()
+	s := string(e.text[from:to])
+	switch n := len(e.kills); {
+	case prev == opKill && n > 0 && to == e.cursor && from < e.cursor:
+		e.kills[n-1] = s + e.kills[n-1]
+	case prev == opKill && n > 0:
+		e.kills[n-1] += s
+	default:
+		e.pushKill(s)
+	}
+	e.text = append(e.text[:from], e.text[to:]...)
+	e.cursor = from
+}
+
+// pushKill adds s to the kill ring.
+func (e *lineEditor) pushKill(s string) {
+	e.kills = append(e.kills, s)
+	if len(e.kills) > killRingSize {
+		e.kills = e.kills[1:]
+	}
+}
+
+// Yank inserts the most recent kill at the cursor.
+func (e *lineEditor) Yank() {
+	if len(e.kills) == 0 {
+		return
+	}
+	e.checkpoint()
+	k := len(e.kills) - 1
+	e.yanked.start, e.yanked.kill = e.cursor, k
+	e.insertString(e.kills[k])
+	e.yanked.end = e.cursor
+	e.last = opYank
+}
+
+// yankPop replaces the text that the previous command yanked with the
+// next older kill. If the yanked text is gone, it does nothing.
+func (e *lineEditor) yankPop() {
+	y := &e.yanked
+	if y.start > y.end || y.end > len(e.text) {
+		return
+	}
+	y.kill = (y.kill + len(e.kills) - 1) % len(e.kills)
+	e.text = append(e.text[:y.start], e.text[y.end:]...)
+	e.cursor = y.start
+	e.insertString(e.kills[y.kill])
+	y.end = e.cursor
+	e.last = opYank
+}
+
+// Visible returns the part of the input that fits into a pane of
+// width w and height h, line by line, and the cursor's column and line
+// within that part. The visible part scrolls to keep the cursor in
+// view.
+func (e *lineEditor) Visible(w, h int) (lines [][]rune, cx, cy int) {
+	w, h = max(0, w), max(1, h)
+	row, col := e.position(e.cursor)
+	if col < e.off {
+		e.off = col
+	}
+	if w > 0 && col >= e.off+w {
+		e.off = col - w + 1
+	}
+	if row < e.top {
+		e.top = row
+	}
+	if row >= e.top+h {
+		e.top = row - h + 1
+	}
+	all := e.lines()
+	// After deleting lines, the pane fills up again.
+	e.top = min(e.top, max(0, len(all)-h))
+	for _, line := range all[e.top:min(len(all), e.top+h)] {
+		line = line[min(e.off, len(line)):]
+		if len(line) > w {
+			line = line[:w]
+		}
+		lines = append(lines, line)
+	}
+	return lines, col - e.off, row - e.top
+}
+
+// HandleKey applies an editing key to the line and reports whether the
+// key was an editing key.
+func (e *lineEditor) HandleKey(ev keyEvent) bool {
+	prev := e.last
+	e.last = opOther
+	if e.meta && ev.key != keyEnter {
+		ev.mod |= modAlt
+	}
+	e.meta = false
+	if e.vi {
+		return e.viKey(ev, prev)
+	}
+	if ev.key == keyEsc && ev.mod == 0 {
+		e.meta = true
+		return true
+	}
+	return e.emacsKey(ev, prev)
+}
+
+// emacsKey applies a key of readline's emacs mode. prev is the kind of
+// the previous command.
+func (e *lineEditor) emacsKey(ev keyEvent, prev editOp) bool {
+	alt := ev.mod&modAlt != 0
+	switch {
+	case ev.key == keyRune && ev.mod&(modCtrl|modAlt) == 0:
+		if prev != opInsert {
+			e.checkpoint()
+		}
+		e.Insert(ev.ch)
+		e.last = opInsert
+	case ev.key == keyEnter && ev.mod&modCtrl == 0:
+		// Enter only gets here with Alt or Shift, see submitKey.
+		e.checkpoint()
+		e.Insert('\n')
+	case ev.key == keyBackspace && alt:
+		e.kill(e.wordLeft(), e.cursor, prev)
+	case ev.key == keyBackspace, ev == ctrl('h'):
+		if e.cursor > 0 {
+			e.checkpoint()
+			e.Backspace()
+		}
+	case ev.key == keyDelete, ev == ctrl('d'):
+		if e.cursor < len(e.text) {
+			e.checkpoint()
+			e.Delete()
+		}
+	case ev.key == keyLeft && alt, ev == keyEvent{key: keyRune, ch: 'b', mod: modAlt}:
+		e.cursor = e.wordLeft()
+	case ev.key == keyRight && alt, ev == keyEvent{key: keyRune, ch: 'f', mod: modAlt}:
+		e.cursor = e.wordRight()
+	case ev.key == keyLeft, ev == ctrl('b'):
+		e.Left()
+	case ev.key == keyRight, ev == ctrl('f'):
+		e.Right()
+	case ev.key == keyHome, ev == ctrl('a'):
+		e.Home()
+	case ev.key == keyEnd, ev == ctrl('e'):
+		e.End()
+	case ev == ctrl('k'):
+		// Like in emacs, Ctrl-K at the end of a line joins the next
+		// line.
+		to := e.lineEnd()
+		if to == e.cursor && to < len(e.text) {
+			to++
+		}
+		e.kill(e.cursor, to, prev)
+	case ev == ctrl('u'):
+		e.kill(e.lineStart(), e.cursor, prev)
+	case ev == ctrl('w'):
+		e.kill(e.blankWordLeft(), e.cursor, prev)
+	case ev == keyEvent{key: keyRune, ch: 'd', mod: modAlt}:
+		e.kill(e.cursor, e.wordRight(), prev)
+	case ev == ctrl('y'):
+		e.Yank()
+	case ev == keyEvent{key: keyRune, ch: 'y', mod: modAlt}:
+		if prev == opYank {
+			e.yankPop()
+		}
+	case ev == undoKey:
+		e.Undo()
+	case ev == redoKey:
+		e.Redo()
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package main
+
+import "testing"
+
+// editKeys applies keys to e. Runes in s are typed as they are, except
+// for the ones that keys maps to other key events.
+func editKeys(e *lineEditor, s string, keys map[rune]keyEvent) {
+	for _, ch := range s {
+		ev, ok := keys[ch]
+		if !ok {
+			ev = keyEvent{key: keyRune, ch: ch}
+		}
+		e.HandleKey(ev)
+	}
+}
+
+func TestLineEditorEmacs(t *testing.T) {
+	keys := map[rune]keyEvent{
+		'A': ctrl('a'), 'E': ctrl('e'), 'K': ctrl('k'), 'U': ctrl('u'), 'W': ctrl('w'),
+		'Y': ctrl('y'), 'y': {key: keyRune, ch: 'y', mod: modAlt},
+		'B': {key: keyRune, ch: 'b', mod: modAlt}, 'F': {key: keyRune, ch: 'f', mod: modAlt},
+		'D': {key: keyRune, ch: 'd', mod: modAlt}, '<': undoKey, '>': redoKey, '~': {key: keyEsc},
+	}
+	tests := []struct {
+		keys, want string
+		cursor     int
+	}{
+		{"go test ./...W", "go test ", 8},
+		{"go test ./...WWY", "go test ./...", 13},
+		{"one two threeBBK", "one ", 4},
+		{"one two threeBBKAFFY", "one two three", 13},
+		{"one two threeAD", " two three", 0},
+		// Consecutive kills go into one kill, and Alt-Y replaces the
+		// yanked kill with the one before.
+		{"one two threeWWAKxYy", "xtwo three", 10},
+		{"one two<", "", 0},
+		{"one two<>", "one two", 7},
+		{"one Wtwo<<", "one ", 4},
+		// Esc, then a key, counts as the key with Alt.
+		{"one two~b", "one two", 4},
+	}
+	for _, tt := range tests {
+		var e lineEditor
+		editKeys(&e, tt.keys, keys)
+		if e.String() != tt.want || e.cursor != tt.cursor {
+			t.Errorf("%q: got %q, cursor %d; want %q, cursor %d", tt.keys, e.String(), e.cursor, tt.want, tt.cursor)
+		}
+	}
+}
+
+// Alt-Y only replaces a yank right before it. Recalling a line from
+// the history in between leaves nothing to replace.
+func TestLineEditorYankPopAfterSet(t *testing.T) {
+	var h inputHistory
+	h.Add("x")
+	var e lineEditor
+	editKeys(&e, "hello world", nil)
+	e.HandleKey(ctrl('w'))
+	e.HandleKey(ctrl('y'))
+	h.HandleKey(keyEvent{key: keyUp}, &e)
+	e.HandleKey(keyEvent{key: keyRune, ch: 'y', mod: modAlt})
+	if e.String() != "x" {
+		t.Errorf("got %q, want %q", e.String(), "x")
+	}
+}
+
+// An Esc before Enter does not keep Enter from submitting.
+func TestLineEditorEscEnter(t *testing.T) {
+	var e lineEditor
+	editKeys(&e, "one", nil)
+	e.HandleKey(keyEvent{key: keyEsc})
+	if !e.submitKey(keyEvent{key: keyEnter}) {
+		t.Error("Enter after Esc does not submit")
+	}
+}
+
+func TestLineEditorVi(t *testing.T) {
+	keys := map[rune]keyEvent{'~': {key: keyEsc}, 'R': ctrl('r')}
+	tests := []struct {
+		keys, want string
+		cursor     int
+		normal     bool
+	}{
+		{"abc def~", "abc def", 6, true},
+		{"abc def~0dw", "def", 0, true},
+		{"abc def~bD", "abc ", 3, true},
+		{"abc def~0cwxyz", "xyz def", 3, false},
+		{"abc def~0dd", "", 0, true},
+		{"abc def~0xp", "bac def", 1, true},
+		{"abc def~0dwuR", "def", 0, true},
+		{"abc def~0rb$X", "bbc df", 5, true},
+		{"abc def~0ea!~Ix", "xabc! def", 1, false},
+	}
+	for _, tt := range tests {
+		e := lineEditor{vi: true}
+		editKeys(&e, tt.keys, keys)
+		if e.String() != tt.want || e.cursor != tt.cursor || e.normal != tt.normal {
+			t.Errorf("%q: got %q, cursor %d, normal %v; want %q, cursor %d, normal %v",
+				tt.keys, e.String(), e.cursor, e.normal, tt.want, tt.cursor, tt.normal)
+		}
+	}
+}
+
+func TestLineEditorMultiLine(t *testing.T) {
+	keys := map[rune]keyEvent{
+		'|': {key: keyEnter, mod: modAlt}, '/': {key: keyEnter, mod: modShift},
+		'A': ctrl('a'), 'E': ctrl('e'), 'K': ctrl('k'), 'U': ctrl('u'), '~': {key: keyEsc},
+	}
+	tests := []struct {
+		keys, want string
+		cursor     int
+	}{
+		{"one|two", "one\ntwo", 7},
+		{"one/two", "one\ntwo", 7},
+		{"one|twoAx", "one\nxtwo", 5},
+		{"one|twoAKK", "one\n", 4},
+		{"one|two^EKx", "onextwo", 4},
+		{"one|two^U", "\ntwo", 0},
+		{"one|two^AUx", "xone\ntwo", 1},
+		{"one|two^Avx", "one\nxtwo", 5},
+	}
+	for _, tt := range tests {
+		var e lineEditor
+		for _, ch := range tt.keys {
+			// The history handles Up and Down, see
+			// TestHistoryMultiLine.
+			switch ch {
+			case '^':
+				e.lineUp()
+			case 'v':
+				e.lineDown()
+			default:
+				editKeys(&e, string(ch), keys)
+			}
+		}
+		if e.String() != tt.want || e.cursor != tt.cursor {
+			t.Errorf("%q: got %q, cursor %d; want %q, cursor %d", tt.keys, e.String(), e.cursor, tt.want, tt.cursor)
+		}
+	}
+
+	// Enter submits any input, and so do Ctrl-Enter and Ctrl-J.
+	var e lineEditor
+	e.Set("one\ntwo\nthree")
+	for _, ev := range []keyEvent{{key: keyEnter}, {key: keyEnter, mod: modCtrl}, ctrl('j')} {
+		if !e.submitKey(ev) {
+			t.Errorf("%v does not submit", ev)
+		}
+	}
+	for _, ev := range []keyEvent{{key: keyEnter, mod: modAlt}, {key: keyEnter, mod: modShift}} {
+		if e.submitKey(ev) {
+			t.Errorf("%v submits", ev)
+		}
+	}
+
+	// The visible part follows the cursor, and fills up the pane again
+	// after deleting lines.
+	lines, cx, cy := e.Visible(5, 2)
+	if len(lines) != 2 || string(lines[0]) != "wo" || string(lines[1]) != "hree" || cx != 4 || cy != 1 {
+		t.Errorf("visible: got %q, cursor %d, %d", lines, cx, cy)
+	}
+	e.lineUp()
+	e.lineUp()
+	lines, cx, cy = e.Visible(5, 2)
+	if string(lines[0]) != "ne" || cx != 2 || cy != 0 {
+		t.Errorf("visible after going up: got %q, cursor %d, %d", lines, cx, cy)
+	}
+	e.Set("x")
+	if lines, _, cy = e.Visible(5, 2); len(lines) != 1 || cy != 0 {
+		t.Errorf("visible after deleting lines: got %q, cursor line %d", lines, cy)
+	}
+	e = lineEditor{}
+	e.Set("one\ntwo")
+	e.Click(1, 1)
+	if e.cursor != 5 {
+		t.Errorf("click: cursor %d", e.cursor)
+	}
+}
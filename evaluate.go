@@ -0,0 +1,230 @@
+//go:build !windows
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hinshun/vt10x"
+	"github.com/pkg/errors"
+)
+
+// The evaluate command is the side-by-side test that the article had
+// to skip. It runs each backend in a virtual terminal, drives it
+// through a scripted scenario, and reports which steps of the sample
+// app definition the backend passes.
+
+func init() {
+	registerCommand("evaluate", runEvaluate)
+}
+
+const (
+	// Size of the virtual terminal, before and after the resize step.
+	evalW, evalH               = 80, 24
+	evalResizedW, evalResizedH = 100, 30
+	// How long a step may take to show its effect on the screen.
+	evalTimeout = 1500 * time.Millisecond
+)
+
+// An evalStep tests one feature. It reports whether the backend passes.
+type evalStep struct {
+	feature string
+	desc    string
+	run     func(v *vterm) bool
+}
+
+var evalScenario = []evalStep{
+	{"layout", "list, output, and input panes appear with their titles and the list items", evalLayout},
+	{"input", "typed text appears in the input pane", evalInput},
+	{"enter", "Enter moves the input text to the output pane", evalEnter},
+	{"resize", "the panes follow a terminal resize", evalResize},
+	{"mouse", "clicking a list item highlights it", evalMouse},
+	{"quit", "Ctrl-C quits the app", evalQuit},
+}
+
+func evalLayout(v *vterm) bool {
+	return v.WaitFor(evalTimeout, func() bool {
+		for _, s := range []string{"List", "Output", "Input", "Line 1"} {
+			if _, _, ok := v.Find(s); !ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// inInput reports whether s is shown in the bottom ih rows of the
+// screen, where all backends place the input pane.
+func inInput(v *vterm, s string) bool {
+	lines := v.Lines()
+	for _, line := range lines[len(lines)-ih:] {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func evalInput(v *vterm) bool {
+	if v.Send("hello") != nil {
+		return false
+	}
+	return v.WaitFor(evalTimeout, func() bool {
+		return inInput(v, "hello")
+	})
+}
+
+func evalEnter(v *vterm) bool {
+	if v.Send("\r") != nil {
+		return false
+	}
+	return v.WaitFor(evalTimeout, func() bool {
+		_, y, ok := v.Find("hello")
+		_, h := v.Size()
+		return ok && y < h-ih && !inInput(v, "hello")
+	})
+}
+
+func evalResize(v *vterm) bool {
+	if v.Resize(evalResizedW, evalResizedH) != nil {
+		return false
+	}
+	// The top right corner moves to the new right edge, the input pane
+	// to the new bottom. (Some libraries draw the bottom right corner
+	// with terminal tricks that the emulator does not always follow.)
+	return v.WaitFor(evalTimeout, func() bool {
+		corner := v.Cell(evalResizedW-1, 0).Char
+		return (corner == '┐' || corner == '╗') && inInput(v, "Input")
+	})
+}
+
+// evalMouse clicks on the third list item. A real terminal only
+// reports mouse events if the app asked for them, so neither does
+// evalMouse.
+func evalMouse(v *vterm) bool {
+	if v.Mode()&vt10x.ModeMouseMask == 0 {
+		return false
+	}
+	x, y, ok := v.Find("Line 3")
+	if !ok {
+		return false
+	}
+	// Press and release the left button, in xterm's SGR encoding with
+	// 1-based coordinates.
+	err := v.Send(fmt.Sprintf("\x1b[<0;%d;%dM\x1b[<0;%d;%dm", x+1, y+1, x+1, y+1))
+	if err != nil {
+		return false
+	}
+	return v.WaitFor(evalTimeout, func() bool {
+		g := v.Cell(x, y)
+		return g.Mode&vtReverse != 0 || g.BG != vt10x.DefaultBG
+	})
+}
+
+func evalQuit(v *vterm) bool {
+	if v.Send("\x03") != nil {
+		return false
+	}
+	select {
+	case <-v.done:
+		return true
+	case <-time.After(evalTimeout):
+		return false
+	}
+}
+
+// An evalResult holds the outcome of the scenario for one backend.
+type evalResult struct {
+	Backend string          `json:"backend"`
+	Pass    map[string]bool `json:"pass"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// evaluate runs the scenario against the named backend. A step fails
+// if the backend has terminated before.
+func evaluate(backend string) evalResult {
+	res := evalResult{Backend: backend, Pass: map[string]bool{}}
+	// The scenario's input must not end up in the user's history.
+	cmd, err := selfCommand(backend, "-history=")
+	if err == nil {
+		var v *vterm
+		v, err = startVterm(cmd, evalW, evalH)
+		if err == nil {
+			defer v.Close()
+			for _, step := range evalScenario {
+				res.Pass[step.feature] = !v.Exited() && step.run(v)
+			}
+			return res
+		}
+	}
+	res.Error = err.Error()
+	for _, step := range evalScenario {
+		res.Pass[step.feature] = false
+	}
+	return res
+}
+
+// runEvaluate evaluates the backends given as arguments, or all of
+// them, and prints the capability matrix as Markdown or JSON.
+func runEvaluate(args []string) error {
+	fs := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the results as JSON instead of Markdown")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		names = backendNames()
+	}
+	var results []evalResult
+	for _, name := range names {
+		if _, ok := backends[name]; !ok {
+			return errors.New("No such backend: " + name)
+		}
+		results = append(results, evaluate(name))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	writeMatrix(os.Stdout, results)
+	return nil
+}
+
+// writeMatrix writes the results as a Markdown table with one row per
+// feature and one column per backend.
+func writeMatrix(w io.Writer, results []evalResult) {
+	fmt.Fprint(w, "| Feature | Description |")
+	for _, res := range results {
+		fmt.Fprintf(w, " %s |", res.Backend)
+	}
+	fmt.Fprint(w, "\n|---|---|")
+	for range results {
+		fmt.Fprint(w, "---|")
+	}
+	fmt.Fprintln(w)
+	for _, step := range evalScenario {
+		fmt.Fprintf(w, "| %s | %s |", step.feature, step.desc)
+		for _, res := range results {
+			mark := "fail"
+			if res.Pass[step.feature] {
+				mark = "pass"
+			}
+			fmt.Fprintf(w, " %s |", mark)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, res := range results {
+		if res.Error != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", res.Backend, res.Error)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// When the evaluation starts the test binary as the app, TUI_TEST_ARGS
+// holds the command line arguments for main. The tests never touch the
+// user's input history.
+func TestMain(m *testing.M) {
+	if args := os.Getenv("TUI_TEST_ARGS"); args != "" {
+		os.Args = append(os.Args[:1], strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+	selfCommand = func(args ...string) (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0])
+		args = append(args, "-history=")
+		cmd.Env = append(os.Environ(), "TUI_TEST_ARGS="+strings.Join(args, " "))
+		return cmd, nil
+	}
+	os.Exit(m.Run())
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, name := range []string{"gocui", "tcell"} {
+		res := evaluate(name)
+		if res.Error != "" {
+			t.Fatalf("%s: %s", name, res.Error)
+		}
+		for _, feature := range []string{"layout", "input", "enter", "resize", "mouse", "quit"} {
+			if !res.Pass[feature] {
+				t.Errorf("%s: %s failed", name, feature)
+			}
+		}
+	}
+}
+
+func TestWriteMatrix(t *testing.T) {
+	var buf bytes.Buffer
+	writeMatrix(&buf, []evalResult{
+		{Backend: "a", Pass: map[string]bool{"layout": true}},
+		{Backend: "b", Pass: map[string]bool{}, Error: "broken"},
+	})
+	got := buf.String()
+	for _, want := range []string{
+		"| Feature | Description | a | b |\n",
+		"| layout | " + evalScenario[0].desc + " | pass | fail |\n",
+		"\nb: broken\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
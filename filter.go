@@ -0,0 +1,108 @@
+package main
+
+import "fmt"
+
+// The output pane can hide the lines that do not match a pattern, much
+// like grep: "&" opens the filter prompt, and the pane shows only the
+// matching lines while the user types. The output itself stays
+// complete, so clearing the filter brings back every line.
+
+// invertKey turns the filter around, like grep -v: the pane shows only
+// the lines that do not match.
+var invertKey = ctrl('v')
+
+// An outputFilter is the state of the filter in the output pane.
+type outputFilter struct {
+	pattern pattern
+	// prompt is set while the user enters the pattern.
+	prompt bool
+	invert bool
+	// lines caches the complete lines that pass the filter, and
+	// checked is the number of output lines that went into the cache.
+	// The unfinished last line of the output can still change, so it
+	// never goes into the cache.
+	lines   []string
+	checked int
+}
+
+// active reports whether the filter hides any lines.
+func (f *outputFilter) active() bool {
+	return f.pattern.re != nil
+}
+
+// pass reports whether line passes the filter.
+func (f *outputFilter) pass(line string) bool {
+	return f.pattern.re.MatchString(line) != f.invert
+}
+
+// reset empties the cache after the filter changed.
+func (f *outputFilter) reset() {
+	f.lines, f.checked = nil, 0
+}
+
+// shown returns the lines that the output pane shows: the lines that
+// pass the filter, or all lines if there is no filter.
+func (o *outputModel) shown() []string {
+	f := &o.filter
+	if !f.active() {
+		return o.Lines()
+	}
+	if len(o.lines) == 0 {
+		return nil
+	}
+	last := len(o.lines) - 1
+	for ; f.checked < last; f.checked++ {
+		if line := o.lines[f.checked]; f.pass(line) {
+			f.lines = append(f.lines, line)
+		}
+	}
+	if line := o.lines[last]; line != "" && f.pass(line) {
+		return append(f.lines[:len(f.lines):len(f.lines)], line)
+	}
+	return f.lines
+}
+
+// startFilter opens the filter prompt with the current pattern.
+func (o *outputModel) startFilter() {
+	o.filter.prompt = true
+	o.filter.pattern.text.End()
+}
+
+// handleFilterKey applies a key to the filter prompt. Enter keeps the
+// filter, Esc or Ctrl-G removes it. Every change of the filter shows
+// the most recent lines that pass it, and runs an active search again.
+func (o *outputModel) handleFilterKey(ev keyEvent) {
+	f := &o.filter
+	switch {
+	case ev.key == keyEnter:
+		f.prompt = false
+		return
+	case stopKeys[ev]:
+		f.prompt = false
+		f.pattern.Reset()
+	case ev == invertKey:
+		f.invert = !f.invert
+	case !f.pattern.HandleKey(ev):
+		return
+	}
+	f.reset()
+	o.scroll = 0
+	o.refind()
+}
+
+// filterTitle returns the part of the title that describes the filter
+// and how many lines pass it.
+func (o *outputModel) filterTitle() string {
+	f := &o.filter
+	if !f.prompt && !f.active() {
+		return ""
+	}
+	t := " &" + f.pattern.String()
+	if f.invert {
+		t += " [invert]"
+	}
+	if f.active() {
+		t += fmt.Sprintf(" (%d of %d)", len(o.shown()), len(o.Lines()))
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputFilter(t *testing.T) {
+	var o outputModel
+	o.Append("GET /a 200\nGET /b 404\nPOST /a 200\n")
+	typeKeys := func(s string) {
+		for _, ch := range s {
+			o.HandleKey(keyEvent{key: keyRune, ch: ch})
+		}
+	}
+
+	typeKeys("&/a")
+	if got := strings.Join(o.Visible(5), ","); got != "GET /a 200,POST /a 200" {
+		t.Errorf("substring: got %q", got)
+	}
+	o.HandleKey(keyEvent{key: keyEnter})
+	// New output goes through the filter, too.
+	o.Append("PUT /a 201\nGET /c ")
+	o.Append("200\n")
+	if got := strings.Join(o.Visible(5), ","); got != "GET /a 200,POST /a 200,PUT /a 201" {
+		t.Errorf("appended: got %q", got)
+	}
+
+	// Edit the pattern into an inverted regex.
+	typeKeys("&")
+	o.HandleKey(keyEvent{key: keyBackspace})
+	o.HandleKey(keyEvent{key: keyBackspace})
+	o.HandleKey(regexKey)
+	o.HandleKey(invertKey)
+	typeKeys(" 2\\d\\d")
+	if got := strings.Join(o.Visible(5), ","); got != "GET /b 404" {
+		t.Errorf("inverted regex: got %q", got)
+	}
+	if got := o.Title(); got != "Output & 2\\d\\d [regex] [invert] (1 of 5)" {
+		t.Errorf("title: got %q", got)
+	}
+
+	// Removing the filter brings back all lines.
+	o.HandleKey(keyEvent{key: keyEnter})
+	o.HandleKey(keyEvent{key: keyEsc})
+	if got := len(o.Visible(5)); got != 5 || o.Title() != "Output" {
+		t.Errorf("filter not removed: %d lines, %q", got, o.Title())
+	}
+}
+
+func TestOutputFilterSearch(t *testing.T) {
+	var o outputModel
+	o.Append("a x\nb\na y\nb x\nb\n")
+	o.Visible(5)
+	typeKeys := func(s string) {
+		for _, ch := range s {
+			o.HandleKey(keyEvent{key: keyRune, ch: ch})
+		}
+	}
+
+	typeKeys("/x")
+	o.HandleKey(keyEvent{key: keyEnter})
+	typeKeys("n")
+	if o.search.cur != (searchMatch{3, 2, 3}) {
+		t.Fatalf("search: got %v", o.search.cur)
+	}
+	// The filter leaves "b x" as line 1 and drops line 3, so the
+	// search starts over in the filtered lines.
+	typeKeys("&b")
+	if o.search.cur != (searchMatch{1, 2, 3}) {
+		t.Errorf("filtered: got %v", o.search.cur)
+	}
+	if got := o.Title(); got != "Output &b (3 of 5) /x (1/1)" {
+		t.Errorf("title: got %q", got)
+	}
+	if got := o.Spans(1); len(got) != 1 || got[0].st != matchStyle(true) {
+		t.Errorf("spans: got %v", got)
+	}
+}
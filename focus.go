@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// paneNames are the names of the panes on the command line. The gocui
+// backend uses them as view names, too.
+var paneNames = map[pane]string{
+	paneList:   "list",
+	paneOutput: "output",
+	paneInput:  "input",
+}
+
+// tabOrder is the order in which Tab moves the focus through the panes.
+// Shift-Tab goes the other way round. The -taborder flag changes it.
+var tabOrder = []pane{paneList, paneOutput, paneInput}
+
+// parseTabOrder sets tabOrder from a comma-separated list of pane
+// names, like "input,list". Panes that are left out cannot receive the
+// focus via Tab.
+func parseTabOrder(s string) error {
+	var order []pane
+	for _, name := range strings.Split(s, ",") {
+		p, ok := paneByName(strings.TrimSpace(name))
+		if !ok {
+			return errors.Errorf("Unknown pane %q", name)
+		}
+		order = append(order, p)
+	}
+	tabOrder = order
+	return nil
+}
+
+// paneByName looks up a pane by its name.
+func paneByName(name string) (pane, bool) {
+	for p, n := range paneNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// A focusRing tracks which pane has the keyboard focus and moves it
+// along tabOrder. The zero value has the focus on the input pane.
+type focusRing struct {
+	cur pane
+}
+
+// Pane returns the focused pane.
+func (f *focusRing) Pane() pane {
+	return f.cur
+}
+
+// Set moves the focus to p, whether or not p is part of tabOrder.
+func (f *focusRing) Set(p pane) {
+	f.cur = p
+}
+
+// Next and Prev move the focus to the next or the previous pane in
+// tabOrder and return that pane. If the focused pane is not part of
+// tabOrder, they start over at one end.
+func (f *focusRing) Next() pane {
+	return f.move(1)
+}
+
+func (f *focusRing) Prev() pane {
+	return f.move(-1)
+}
+
+func (f *focusRing) move(dir int) pane {
+	n := len(tabOrder)
+	if n == 0 {
+		return f.cur
+	}
+	i := -1
+	for j, p := range tabOrder {
+		if p == f.cur {
+			i = j
+		}
+	}
+	switch {
+	case i >= 0:
+		i = (i + dir + n) % n
+	case dir > 0:
+		i = 0
+	default:
+		i = n - 1
+	}
+	f.cur = tabOrder[i]
+	return f.cur
+}
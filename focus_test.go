@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestTabOrder(t *testing.T) {
+	defer func(order []pane) { tabOrder = order }(tabOrder)
+	err := parseTabOrder("input, list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f focusRing
+	got := []pane{f.Next(), f.Next(), f.Prev(), f.Prev()}
+	want := []pane{paneList, paneInput, paneList, paneInput}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	// Panes outside the tab order can still be focused, and Tab
+	// returns to the order from there.
+	f.Set(paneOutput)
+	if p := f.Next(); p != paneInput {
+		t.Errorf("got %v after the output pane", p)
+	}
+	if err := parseTabOrder("list,status"); err == nil {
+		t.Error("unknown pane accepted")
+	}
+}
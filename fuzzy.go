@@ -0,0 +1,193 @@
+package main
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The list pane filters its items as the user types. The filter is
+// fuzzy: an item matches if it contains the typed characters in the
+// same order, but not necessarily next to each other. Items where the
+// characters form words or word starts rank first.
+
+// Scores of the matched characters.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 6
+	penaltyGap       = 1
+	// penaltyGapLimit is the largest penalty for the gap before a
+	// matched character, so that one long gap does not weigh more
+	// than all bonuses.
+	penaltyGapLimit = 8
+)
+
+// maxStarts is the largest number of starting points that a match
+// tries within an item. It bounds the work for long items with many
+// occurrences of the query's first character.
+const maxStarts = 16
+
+// A fuzzyMatcher matches items against a query. It reuses its buffers
+// from item to item, so that filtering many items allocates little.
+type fuzzyMatcher struct {
+	query []rune
+	// fold is set if the match ignores case.
+	fold bool
+	text []rune
+	p    []int
+}
+
+// newFuzzyMatcher returns a matcher for query. The match ignores case
+// unless query contains upper-case letters.
+func newFuzzyMatcher(query []rune) *fuzzyMatcher {
+	m := &fuzzyMatcher{query: query, fold: true}
+	for _, ch := range query {
+		if unicode.IsUpper(ch) {
+			m.fold = false
+		}
+	}
+	return m
+}
+
+// eq reports whether the rune a of an item matches the rune b of the
+// query.
+func (m *fuzzyMatcher) eq(a, b rune) bool {
+	if a == b || !m.fold {
+		return a == b
+	}
+	if a < utf8.RuneSelf {
+		return 'A' <= a && a <= 'Z' && a+'a'-'A' == b
+	}
+	return unicode.ToLower(a) == b
+}
+
+// match reports whether the query matches s, how good the match is,
+// and, if pos is not nil, appends the positions of the matched runes
+// in s to pos.
+func (m *fuzzyMatcher) match(s string, pos []int) (score int, _ []int, ok bool) {
+	query := m.query
+	if len(query) == 0 {
+		return 0, pos, true
+	}
+	// Most items do not match at all, and finding out is cheap.
+	i := 0
+	text := m.text[:0]
+	for _, ch := range s {
+		if i < len(query) && m.eq(ch, query[i]) {
+			i++
+		}
+		text = append(text, ch)
+	}
+	m.text = text
+	if i < len(query) {
+		return 0, pos, false
+	}
+
+	// Try the occurrences of the first query character as the start
+	// of a match, and match the rest greedily. The best try wins.
+	best, tries := -1, 0
+	n := len(pos)
+	for start := 0; start < len(text) && tries < maxStarts; start++ {
+		if !m.eq(text[start], query[0]) {
+			continue
+		}
+		tries++
+		p := append(m.p[:0], start)
+		for i := start + 1; i < len(text) && len(p) < len(query); i++ {
+			if m.eq(text[i], query[len(p)]) {
+				p = append(p, i)
+			}
+		}
+		m.p = p
+		if len(p) < len(query) {
+			// Later starts cannot match either.
+			break
+		}
+		if sc := fuzzyScore(text, p); best < 0 || sc > score {
+			score, best = sc, start
+			if pos != nil {
+				pos = append(pos[:n], p...)
+			}
+		}
+	}
+	return score, pos, true
+}
+
+// fuzzyMatch reports whether query matches s, how good the match is,
+// and the positions of the matched runes in s. The match ignores case
+// unless query contains upper-case letters.
+func fuzzyMatch(s string, query []rune) (score int, pos []int, ok bool) {
+	if len(query) == 0 {
+		return 0, nil, true
+	}
+	return newFuzzyMatcher(query).match(s, make([]int, 0, len(query)))
+}
+
+// fuzzyScore rates the matched positions pos in text.
+func fuzzyScore(text []rune, pos []int) int {
+	score := 0
+	for i, p := range pos {
+		score += scoreMatch
+		if p == 0 || isBoundary(text[p-1], text[p]) {
+			score += bonusBoundary
+		}
+		gap := p
+		if i > 0 {
+			gap = p - pos[i-1] - 1
+		}
+		if i > 0 && gap == 0 {
+			score += bonusConsecutive
+		}
+		score -= min(gap*penaltyGap, penaltyGapLimit)
+	}
+	return score
+}
+
+// isBoundary reports whether a word starts at cur, given the rune prev
+// before it: after a non-alphanumeric rune, as an upper-case letter
+// after a lower-case one, or as a digit after a letter.
+func isBoundary(prev, cur rune) bool {
+	alnum := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	switch {
+	case !alnum(prev):
+		return alnum(cur)
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return true
+	}
+	return false
+}
+
+// fuzzyFilter returns the indices of the items in candidates that match
+// query, best match first. Of the items with the same score, shorter
+// ones come first, and otherwise they keep their order in items, no
+// matter how an earlier query ranked the candidates.
+func fuzzyFilter(items []string, candidates []int, query []rune) []int {
+	type result struct {
+		index, score int
+	}
+	m := newFuzzyMatcher(query)
+	var results []result
+	for _, i := range candidates {
+		if score, _, ok := m.match(items[i], nil); ok {
+			results = append(results, result{i, score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		ra, rb := results[a], results[b]
+		if ra.score != rb.score {
+			return ra.score > rb.score
+		}
+		if la, lb := len(items[ra.index]), len(items[rb.index]); la != lb {
+			return la < lb
+		}
+		return ra.index < rb.index
+	})
+	view := make([]int, len(results))
+	for i, r := range results {
+		view[i] = r.index
+	}
+	return view
+}
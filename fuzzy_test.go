@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		s, query string
+		pos      []int
+		ok       bool
+	}{
+		{"Line 1", "", nil, true},
+		{"Line 1", "l1", []int{0, 5}, true},
+		{"Line 1", "L1", []int{0, 5}, true},
+		{"Line 1", "1l", nil, false},
+		// Upper case in the query makes the match case-sensitive.
+		{"line 1", "L1", nil, false},
+		// The match prefers word starts to the first occurrence.
+		{"proof of fooBar", "fb", []int{9, 12}, true},
+		{"a_fuzzy_finder", "ff", []int{2, 8}, true},
+	}
+	for _, tt := range tests {
+		_, pos, ok := fuzzyMatch(tt.s, []rune(tt.query))
+		if ok != tt.ok || ok && !reflect.DeepEqual(pos, tt.pos) {
+			t.Errorf("fuzzyMatch(%q, %q): got %v, %v, want %v, %v", tt.s, tt.query, pos, ok, tt.pos, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	items := []string{"my config file", "conf", "fcon", "ContentFile", "cake"}
+	got := fuzzyFilter(items, []int{0, 1, 2, 3, 4}, []rune("cf"))
+	// Matches at word starts and without gaps rank first, and "fcon"
+	// and "cake" do not match at all.
+	if want := []int{3, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Narrowing the query does not carry over the order of the
+	// previous results: items with the same score and length keep
+	// their order in items.
+	items = []string{"_a_b", "a__b"}
+	view := fuzzyFilter(items, []int{0, 1}, []rune("a"))
+	if want := []int{1, 0}; !reflect.DeepEqual(view, want) {
+		t.Errorf("a: got %v, want %v", view, want)
+	}
+	got = fuzzyFilter(items, view, []rune("ab"))
+	if want := []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ab: got %v, want %v", got, want)
+	}
+}
+
+func BenchmarkListFilter(b *testing.B) {
+	items := make([]string, 50000)
+	for i := range items {
+		items[i] = fmt.Sprintf("item %d: some/path/to/file_%d.go", i, i*7)
+	}
+	var l listModel
+	l.SetItems(items)
+	for b.Loop() {
+		for _, ch := range "file_7" {
+			l.HandleKey(keyEvent{key: keyRune, ch: ch})
+		}
+		l.HandleKey(keyEvent{key: keyEsc})
+	}
+}
@@ -1,13 +1,29 @@
 module github.com/appliedgo/tui
 
-go 1.13
+go 1.24.0
 
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/creack/pty v1.1.24
+	github.com/gdamore/tcell/v2 v2.13.10
 	github.com/gizak/termui v2.3.0+incompatible
+	github.com/hinshun/vt10x v0.0.0-20220119200601-820417d04eec
 	github.com/jroimartin/gocui v0.5.0
+	github.com/nsf/termbox-go v1.1.1
+	github.com/pkg/errors v0.9.1
+	github.com/rivo/tview v0.42.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gdamore/encoding v1.0.1 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
 	github.com/maruel/panicparse v1.6.1 // indirect
+	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
-	github.com/pkg/errors v0.9.1
+	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/stretchr/testify v1.7.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/term v0.37.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
 )
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// What happens to a line that the user enters, unless it is a slash
+// command, is up to an InputHandler. The sample app echoes the lines,
+// but a tool that embeds the layout can do anything with them. main
+// registers the built-in handlers, and the -handler flag or the
+// /handler command picks one.
+
+// An InputHandler handles a line that the user entered in the input
+// pane. Writing to out appends to the output pane. An error goes into
+// the output pane, too.
+type InputHandler interface {
+	HandleInput(line string, out io.Writer) error
+}
+
+// InputHandlerFunc turns a function into an InputHandler.
+type InputHandlerFunc func(line string, out io.Writer) error
+
+func (f InputHandlerFunc) HandleInput(line string, out io.Writer) error {
+	return f(line, out)
+}
+
+// inputHandlers holds the handlers that the user can pick by name.
+var inputHandlers = map[string]InputHandler{}
+
+func registerInputHandler(name string, h InputHandler) {
+	inputHandlers[name] = h
+}
+
+// inputHandlerNames returns the names of all registered handlers in
+// alphabetical order.
+func inputHandlerNames() []string {
+	names := make([]string, 0, len(inputHandlers))
+	for name := range inputHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inputHandler handles the lines that the user enters, and
+// inputHandlerName is the name it is registered under. The app echoes
+// the lines unless main picks another handler.
+var (
+	inputHandler     InputHandler = InputHandlerFunc(echoInput)
+	inputHandlerName              = "echo"
+)
+
+// setInputHandler makes the registered handler name the inputHandler.
+func setInputHandler(name string) error {
+	h, ok := inputHandlers[name]
+	if !ok {
+		return errors.Errorf("unknown input handler %q", name)
+	}
+	inputHandler, inputHandlerName = h, name
+	return nil
+}
+
+// echoInput writes the line to out as it is.
+func echoInput(line string, out io.Writer) error {
+	_, err := fmt.Fprintln(out, line)
+	return err
+}
+
+// jsonInput writes the JSON value in line to out, indented.
+func jsonInput(line string, out io.Writer) error {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, []byte(line), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "json")
+	}
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(out)
+	return err
+}
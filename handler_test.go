@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJSONInput(t *testing.T) {
+	var buf bytes.Buffer
+	err := jsonInput(`{"a": [1, 2], "b": {}}`, &buf)
+	want := "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}\n"
+	if err != nil || buf.String() != want {
+		t.Errorf("got %q, %v", buf.String(), err)
+	}
+	if err := jsonInput(`{"a": }`, io.Discard); err == nil {
+		t.Error("no error for invalid JSON")
+	}
+}
+
+func TestInputHandler(t *testing.T) {
+	registerInputHandler("calc", InputHandlerFunc(calcInput))
+	registerInputHandler("upper", InputHandlerFunc(func(line string, out io.Writer) error {
+		_, err := io.WriteString(out, strings.ToUpper(line)+"\n")
+		return err
+	}))
+	t.Cleanup(func() {
+		delete(inputHandlers, "calc")
+		delete(inputHandlers, "upper")
+		inputHandler, inputHandlerName = InputHandlerFunc(echoInput), "echo"
+	})
+
+	b := newHeadlessBackend(60, 12)
+	a := &app{b: b}
+	for _, line := range []string{"hello", "/handler upper", "hello", "//hello", "/handler calc", "1 + 1", "1 +", "/handler nope"} {
+		a.submit(line)
+	}
+	want := []string{
+		"hello",
+		"HELLO",
+		"/HELLO",
+		"1 + 1 = 2",
+		"Error: calc: unexpected end at column 4",
+		`Error: /handler: unknown input handler "nope"`,
+	}
+	if got := b.output.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("output: got %q, want %q", got, want)
+	}
+	if inputHandlerName != "calc" {
+		t.Errorf("handler: got %q", inputHandlerName)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import "strings"
+
+// The headless backend runs the sample app on a virtual screen in
+// memory instead of a terminal, so that tests can drive the app with
+// key and resize events and inspect the result as plain text. It is
+// not registered as a command line option, as there is nothing to see.
+type headlessBackend struct {
+	cellPanes
+	screen *virtualScreen
+	events chan headlessEvent
+}
+
+// A headlessEvent is a key press, a mouse event, a resize, or - if
+// all are unset - just a request for the current screen. Run replies
+// with a copy of the screen after processing the event.
+type headlessEvent struct {
+	key   *keyEvent
+	mouse *mouseEvent
+	w, h  int
+	reply chan *virtualScreen
+}
+
+func newHeadlessBackend(w, h int) *headlessBackend {
+	return &headlessBackend{
+		screen: newVirtualScreen(w, h),
+		events: make(chan headlessEvent),
+	}
+}
+
+func (b *headlessBackend) Init() error { return nil }
+
+func (b *headlessBackend) Close() {}
+
+// Run processes injected events until a key event makes the app quit.
+func (b *headlessBackend) Run() error {
+	b.draw(b.screen)
+	for ev := range b.events {
+		quit := false
+		switch {
+		case ev.key != nil:
+			quit = b.handleKey(*ev.key, b.screen.w, b.screen.h)
+		case ev.mouse != nil:
+			b.handleMouse(*ev.mouse, b.screen.w, b.screen.h)
+		case ev.w > 0 && ev.h > 0:
+			b.screen.Resize(ev.w, ev.h)
+		}
+		b.draw(b.screen)
+		ev.reply <- b.screen.clone()
+		if quit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// send hands ev to Run and waits for the resulting screen.
+func (b *headlessBackend) send(ev headlessEvent) *virtualScreen {
+	ev.reply = make(chan *virtualScreen)
+	b.events <- ev
+	return <-ev.reply
+}
+
+// Key injects a key event.
+func (b *headlessBackend) Key(ev keyEvent) *virtualScreen {
+	return b.send(headlessEvent{key: &ev})
+}
+
+// Type injects a key event for each character of s.
+func (b *headlessBackend) Type(s string) *virtualScreen {
+	var vs *virtualScreen
+	for _, ch := range s {
+		vs = b.Key(keyEvent{key: keyRune, ch: ch})
+	}
+	return vs
+}
+
+// Mouse injects a mouse event.
+func (b *headlessBackend) Mouse(ev mouseEvent) *virtualScreen {
+	return b.send(headlessEvent{mouse: &ev})
+}
+
+// Click injects a click with the left button at x, y.
+func (b *headlessBackend) Click(x, y int) *virtualScreen {
+	b.Mouse(mouseEvent{x: x, y: y, button: mouseLeft})
+	return b.Mouse(mouseEvent{x: x, y: y, button: mouseRelease})
+}
+
+// Drag injects a drag with the left button from x0, y0 to x1, y1.
+func (b *headlessBackend) Drag(x0, y0, x1, y1 int) *virtualScreen {
+	b.Mouse(mouseEvent{x: x0, y: y0, button: mouseLeft})
+	b.Mouse(mouseEvent{x: x1, y: y1, button: mouseLeft, motion: true})
+	return b.Mouse(mouseEvent{x: x1, y: y1, button: mouseRelease})
+}
+
+// Resize injects a resize event.
+func (b *headlessBackend) Resize(w, h int) *virtualScreen {
+	return b.send(headlessEvent{w: w, h: h})
+}
+
+// Screen returns the current screen.
+func (b *headlessBackend) Screen() *virtualScreen {
+	return b.send(headlessEvent{})
+}
+
+// A virtualScreen is a canvas that keeps its cells in memory.
+type virtualScreen struct {
+	w, h   int
+	cells  []virtualCell
+	cx, cy int
+	cursor bool
+}
+
+type virtualCell struct {
+	ch rune
+	st style
+}
+
+func newVirtualScreen(w, h int) *virtualScreen {
+	vs := &virtualScreen{}
+	vs.Resize(w, h)
+	return vs
+}
+
+// Resize changes the size of the screen and clears it.
+func (vs *virtualScreen) Resize(w, h int) {
+	vs.w, vs.h = w, h
+	vs.cells = make([]virtualCell, w*h)
+	vs.Clear()
+}
+
+func (vs *virtualScreen) Size() (int, int) {
+	return vs.w, vs.h
+}
+
+func (vs *virtualScreen) Clear() {
+	for i := range vs.cells {
+		vs.cells[i] = virtualCell{ch: ' '}
+	}
+}
+
+func (vs *virtualScreen) SetCell(x, y int, ch rune, st style) {
+	if x < 0 || x >= vs.w || y < 0 || y >= vs.h {
+		return
+	}
+	vs.cells[y*vs.w+x] = virtualCell{ch: ch, st: st}
+}
+
+func (vs *virtualScreen) ShowCursor(x, y int) {
+	vs.cx, vs.cy, vs.cursor = x, y, true
+}
+
+func (vs *virtualScreen) HideCursor() {
+	vs.cursor = false
+}
+
+// Cell returns the character and style at x, y.
+func (vs *virtualScreen) Cell(x, y int) (rune, style) {
+	if x < 0 || x >= vs.w || y < 0 || y >= vs.h {
+		return 0, style{}
+	}
+	c := vs.cells[y*vs.w+x]
+	return c.ch, c.st
+}
+
+// Cursor returns the cursor position and whether the cursor is shown.
+func (vs *virtualScreen) Cursor() (x, y int, visible bool) {
+	return vs.cx, vs.cy, vs.cursor
+}
+
+// Line returns row y as text.
+func (vs *virtualScreen) Line(y int) string {
+	var sb strings.Builder
+	for x := 0; x < vs.w; x++ {
+		ch, _ := vs.Cell(x, y)
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+// String returns the whole screen as text, one line per row.
+func (vs *virtualScreen) String() string {
+	lines := make([]string, vs.h)
+	for y := range lines {
+		lines[y] = vs.Line(y)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (vs *virtualScreen) clone() *virtualScreen {
+	c := *vs
+	c.cells = append([]virtualCell(nil), vs.cells...)
+	return &c
+}
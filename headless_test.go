@@ -0,0 +1,396 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// startHeadless runs the sample app on a headless backend of the given
+// size. The app quits when the test ends.
+func startHeadless(t *testing.T, w, h int) *headlessBackend {
+	t.Helper()
+	b := newHeadlessBackend(w, h)
+	done := make(chan error)
+	go func() {
+		done <- runBackend(b)
+	}()
+	t.Cleanup(func() {
+		b.Key(ctrl('c'))
+		err := <-done
+		if err != nil {
+			t.Error("runBackend:", err)
+		}
+	})
+	return b
+}
+
+// frameAt reports whether vs shows a frame with the given corners.
+func frameAt(vs *virtualScreen, r rect) bool {
+	corners := []struct {
+		x, y int
+		ch   rune
+	}{
+		{r.x0, r.y0, '┌'}, {r.x1, r.y0, '┐'}, {r.x0, r.y1, '└'}, {r.x1, r.y1, '┘'},
+	}
+	for _, c := range corners {
+		if ch, _ := vs.Cell(c.x, c.y); ch != c.ch {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPaneRects(t *testing.T) {
+	lr, or, ir := paneSizes{}.paneRects(80, 24)
+	if lr != (rect{0, 0, lw, 23}) {
+		t.Errorf("list: got %v", lr)
+	}
+	// The output pane starts right of the list pane and ends above
+	// the input pane.
+	if or.x0 != lr.x1+1 || or.y1 != ir.y0-1 || or.x1 != 79 {
+		t.Errorf("output: got %v", or)
+	}
+	// The input pane is ih rows high, both borders included.
+	if ir.y1-ir.y0+1 != ih || ir.y1 != 23 {
+		t.Errorf("input: got %v", ir)
+	}
+}
+
+func TestHeadlessLayout(t *testing.T) {
+	sizes := []struct{ w, h int }{{80, 24}, {50, 10}}
+	for _, size := range sizes {
+		b := startHeadless(t, size.w, size.h)
+		vs := b.Screen()
+		lr, or, ir := paneSizes{}.paneRects(size.w, size.h)
+		for name, r := range map[string]rect{"list": lr, "output": or, "input": ir} {
+			if !frameAt(vs, r) {
+				t.Errorf("%dx%d: no %s frame at %v:\n%s", size.w, size.h, name, r, vs)
+			}
+		}
+		if !strings.Contains(vs.Line(1), "Line 1") {
+			t.Errorf("%dx%d: list items missing:\n%s", size.w, size.h, vs)
+		}
+	}
+}
+
+func TestHeadlessEnter(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	_, or, ir := paneSizes{}.paneRects(60, 12)
+
+	vs := b.Type("hello")
+	if got := vs.Line(ir.y0 + 1); !strings.Contains(got, "hello") {
+		t.Errorf("input line: got %q", got)
+	}
+	if x, y, ok := vs.Cursor(); !ok || x != ir.x0+1+len("hello") || y != ir.y0+1 {
+		t.Errorf("cursor: got %d,%d (visible: %v)", x, y, ok)
+	}
+
+	vs = b.Key(keyEvent{key: keyEnter})
+	// The first output line says how to quit; the submitted text
+	// follows below.
+	if got := vs.Line(or.y0 + 2); !strings.Contains(got, "hello") {
+		t.Errorf("output line: got %q\n%s", got, vs)
+	}
+	if got := vs.Line(ir.y0 + 1); strings.Contains(got, "hello") {
+		t.Errorf("input not cleared: got %q", got)
+	}
+}
+
+func TestHeadlessMultiLine(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	b.Type("one")
+	b.Key(keyEvent{key: keyEnter, mod: modAlt})
+	b.Type("two")
+	b.Key(keyEvent{key: keyEnter, mod: modShift})
+	vs := b.Type("three")
+
+	// The input pane grows to fit the lines, and the output pane
+	// shrinks.
+	sizes := paneSizes{inputLines: 3}
+	_, or, ir := sizes.paneRects(60, 12)
+	if !frameAt(vs, ir) || !frameAt(vs, or) || ir.y1-ir.y0+1 != 5 {
+		t.Errorf("no grown input frame at %v:\n%s", ir, vs)
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if got := vs.Line(ir.y0 + 1 + i); !strings.Contains(got, want) {
+			t.Errorf("input line %d: got %q", i, got)
+		}
+	}
+
+	vs = b.Key(keyEvent{key: keyEnter, mod: modCtrl})
+	_, or, ir = paneSizes{}.paneRects(60, 12)
+	if !frameAt(vs, ir) {
+		t.Errorf("input frame did not shrink back:\n%s", vs)
+	}
+	for i, want := range []string{"one", "two", "three"} {
+		if got := vs.Line(or.y0 + 2 + i); !strings.Contains(got, want) {
+			t.Errorf("output line %d: got %q\n%s", i, got, vs)
+		}
+	}
+}
+
+func TestHeadlessResize(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	b.Type("abc")
+	vs := b.Resize(40, 8)
+	lr, or, ir := paneSizes{}.paneRects(40, 8)
+	if !frameAt(vs, lr) || !frameAt(vs, or) || !frameAt(vs, ir) {
+		t.Errorf("frames not adjusted:\n%s", vs)
+	}
+	if got := vs.Line(ir.y0 + 1); !strings.Contains(got, "abc") {
+		t.Errorf("input lost on resize: got %q", got)
+	}
+}
+
+func TestHeadlessList(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	lr, or, _ := paneSizes{}.paneRects(60, 12)
+
+	b.Key(keyEvent{key: keyTab})
+	b.Key(keyEvent{key: keyDown})
+	vs := b.Key(keyEvent{key: keyDown})
+	if ch, st := vs.Cell(lr.x0+1, lr.y0+3); ch != 'L' || !st.reverse {
+		t.Errorf("Line 3 not highlighted: got %q %+v\n%s", ch, st, vs)
+	}
+	if _, _, ok := vs.Cursor(); ok {
+		t.Error("cursor visible while the list has the focus")
+	}
+
+	vs = b.Key(keyEvent{key: keyEnter})
+	if got := vs.Line(or.y0 + 2); !strings.Contains(got, "Line 3") {
+		t.Errorf("output line: got %q\n%s", got, vs)
+	}
+
+	// The selection stops at the ends of the list.
+	b.Key(keyEvent{key: keyPgDn})
+	vs = b.Key(keyEvent{key: keyDown})
+	if _, st := vs.Cell(lr.x0+1, lr.y0+5); !st.reverse {
+		t.Errorf("Line 5 not highlighted:\n%s", vs)
+	}
+	vs = b.Key(keyEvent{key: keyHome})
+	if _, st := vs.Cell(lr.x0+1, lr.y0+1); !st.reverse {
+		t.Errorf("Line 1 not highlighted:\n%s", vs)
+	}
+
+	// Back in the input pane, keys go to the line editor again.
+	b.Key(keyEvent{key: keyBacktab})
+	vs = b.Type("x")
+	if _, st := vs.Cell(lr.x0+1, lr.y0+1); st.reverse {
+		t.Error("selection still reversed after leaving the list")
+	}
+	if _, _, ok := vs.Cursor(); !ok {
+		t.Error("cursor hidden in the input pane")
+	}
+}
+
+func TestHeadlessFocus(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	lr, or, ir := paneSizes{}.paneRects(60, 12)
+	focused := func(vs *virtualScreen, r rect) bool {
+		_, st := vs.Cell(r.x0, r.y0)
+		return st == borderStyle(true)
+	}
+
+	vs := b.Screen()
+	if !focused(vs, ir) || focused(vs, lr) || focused(vs, or) {
+		t.Errorf("input pane not highlighted at start")
+	}
+	for _, want := range []rect{lr, or, ir, lr} {
+		vs = b.Key(keyEvent{key: keyTab})
+		if !focused(vs, want) {
+			t.Errorf("Tab: pane at %v not highlighted", want)
+		}
+	}
+	vs = b.Key(keyEvent{key: keyBacktab})
+	if !focused(vs, ir) {
+		t.Errorf("Shift-Tab: input pane not highlighted")
+	}
+
+	// Keys for the input pane have no effect while the output pane
+	// has the focus.
+	b.Key(keyEvent{key: keyBacktab})
+	vs = b.Type("abc")
+	if got := vs.Line(ir.y0 + 1); strings.Contains(got, "abc") {
+		t.Errorf("input pane took keys without focus: %q", got)
+	}
+}
+
+func TestHeadlessMouse(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	lr, or, ir := paneSizes{}.paneRects(60, 12)
+
+	// A click on an item focuses the list and selects the item.
+	vs := b.Click(lr.x0+3, lr.y0+4)
+	if _, st := vs.Cell(lr.x0+1, lr.y0+4); !st.reverse {
+		t.Errorf("Line 4 not selected:\n%s", vs)
+	}
+	if _, st := vs.Cell(lr.x0, lr.y0); st != borderStyle(true) {
+		t.Error("list pane not focused")
+	}
+	vs = b.Key(keyEvent{key: keyEnter})
+	if got := vs.Line(or.y0 + 2); !strings.Contains(got, "Line 4") {
+		t.Errorf("output line: got %q", got)
+	}
+
+	// A click on the input pane's frame focuses it, too.
+	vs = b.Click(ir.x1, ir.y0+1)
+	if _, st := vs.Cell(ir.x0, ir.y0); st != borderStyle(true) {
+		t.Error("input pane not focused")
+	}
+
+	for i := 0; i < 20; i++ {
+		b.Type(fmt.Sprint(i))
+		b.Key(keyEvent{key: keyEnter})
+	}
+	last := or.y1 - 1
+	vs = b.Screen()
+	if got := vs.Line(last); !strings.Contains(got, "19") {
+		t.Fatalf("last output line: got %q", got)
+	}
+	vs = b.Mouse(mouseEvent{x: or.x0 + 5, y: or.y0 + 2, button: mouseWheelUp})
+	if got := vs.Line(last); !strings.Contains(got, fmt.Sprint(19-wheelLines)) {
+		t.Errorf("wheel up: got %q", got)
+	}
+	// The wheel only scrolls the output pane while the pointer is
+	// over it.
+	vs = b.Mouse(mouseEvent{x: lr.x0 + 5, y: lr.y0 + 2, button: mouseWheelDown})
+	if got := vs.Line(last); !strings.Contains(got, fmt.Sprint(19-wheelLines)) {
+		t.Errorf("wheel over the list: got %q", got)
+	}
+	vs = b.Mouse(mouseEvent{x: or.x0 + 5, y: or.y0 + 2, button: mouseWheelDown})
+	if got := vs.Line(last); !strings.Contains(got, "19") {
+		t.Errorf("wheel down: got %q", got)
+	}
+}
+
+func TestHeadlessDrag(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	lr, _, ir := paneSizes{}.paneRects(60, 12)
+
+	// Either border of the splitter can be grabbed; the grabbed border
+	// follows the pointer.
+	b.Drag(lr.x1+1, 5, lr.x1+11, 7)
+	vs := b.Drag(ir.x1-3, ir.y0, ir.x1-3, ir.y0-2)
+	var want paneSizes
+	want.set(lw+10, ih+2, 60, 12)
+	wl, wo, wi := want.paneRects(60, 12)
+	for name, r := range map[string]rect{"list": wl, "output": wo, "input": wi} {
+		if !frameAt(vs, r) {
+			t.Errorf("no %s frame at %v:\n%s", name, r, vs)
+		}
+	}
+	if wl.x1 != lr.x1+10 || wi.y0 != ir.y0-2 {
+		t.Errorf("got list %v and input %v", wl, wi)
+	}
+
+	// Moving the mouse after the release does not drag anymore.
+	vs = b.Mouse(mouseEvent{x: 5, y: 5, button: mouseLeft, motion: true})
+	if !frameAt(vs, wl) {
+		t.Errorf("list resized after the drag:\n%s", vs)
+	}
+}
+
+func TestHeadlessZoom(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	b.Key(keyEvent{key: keyUp, mod: modCtrl})
+	vs := b.Key(keyEvent{key: keyRight, mod: modCtrl})
+	var want paneSizes
+	want.Resize(1, 1, 60, 12)
+	wl, wo, wi := want.paneRects(60, 12)
+	for name, r := range map[string]rect{"list": wl, "output": wo, "input": wi} {
+		if !frameAt(vs, r) {
+			t.Errorf("no %s frame at %v:\n%s", name, r, vs)
+		}
+	}
+
+	// The zoomed input pane covers the screen and keeps the cursor.
+	vs = b.Key(zoomKey)
+	if !frameAt(vs, rect{0, 0, 59, 11}) || strings.Contains(vs.String(), "List") {
+		t.Errorf("input pane not zoomed:\n%s", vs)
+	}
+	if x, y, ok := vs.Cursor(); !ok || x != 1 || y != 1 {
+		t.Errorf("cursor at %d, %d", x, y)
+	}
+	// The zoomed pane follows the focus.
+	vs = b.Key(keyEvent{key: keyTab})
+	if !strings.Contains(vs.Line(1), "Line 1") {
+		t.Errorf("list pane not zoomed:\n%s", vs)
+	}
+	vs = b.Key(zoomKey)
+	if !frameAt(vs, wl) || !frameAt(vs, wo) || !frameAt(vs, wi) {
+		t.Errorf("layout not restored:\n%s", vs)
+	}
+}
+
+func TestHeadlessScrollback(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	_, or, _ := paneSizes{}.paneRects(60, 12)
+	for i := 0; i < 20; i++ {
+		b.Type(fmt.Sprint(i))
+		b.Key(keyEvent{key: keyEnter})
+	}
+	b.Key(keyEvent{key: keyBacktab})
+	vs := b.Key(keyEvent{key: keyUp})
+	last := or.y1 - 1
+	if got := vs.Line(last); !strings.Contains(got, "18") {
+		t.Errorf("line up: got %q", got)
+	}
+	if got := vs.Line(or.y0); !strings.Contains(got, "Output [+1]") {
+		t.Errorf("title: got %q", got)
+	}
+	vs = b.Key(keyEvent{key: keyHome})
+	if got := vs.Line(or.y0 + 1); !strings.Contains(got, "Press Ctrl-C") {
+		t.Errorf("top: got %q", got)
+	}
+	vs = b.Key(keyEvent{key: keyEnd})
+	if got := vs.Line(or.y0); strings.Contains(got, "+") {
+		t.Errorf("title at the end: got %q", got)
+	}
+}
+
+func TestHeadlessSearch(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	_, or, _ := paneSizes{}.paneRects(60, 12)
+	for _, s := range []string{"foo", "bar", "a foo"} {
+		b.Type(s)
+		b.Key(keyEvent{key: keyEnter})
+	}
+	b.Key(keyEvent{key: keyBacktab})
+	vs := b.Type("/foo")
+	if _, st := vs.Cell(or.x0+1, or.y0+2); st != matchStyle(true) {
+		t.Errorf("current match not highlighted:\n%s", vs)
+	}
+	if _, st := vs.Cell(or.x0+3, or.y0+4); st != matchStyle(false) {
+		t.Errorf("other match not highlighted:\n%s", vs)
+	}
+	b.Key(keyEvent{key: keyEnter})
+	vs = b.Type("n")
+	if _, st := vs.Cell(or.x0+3, or.y0+4); st != matchStyle(true) {
+		t.Errorf("n did not move to the next match:\n%s", vs)
+	}
+	vs = b.Key(keyEvent{key: keyEsc})
+	if _, st := vs.Cell(or.x0+3, or.y0+4); st == matchStyle(true) {
+		t.Error("Esc did not end the search")
+	}
+}
+
+func TestHeadlessListFilter(t *testing.T) {
+	b := startHeadless(t, 60, 12)
+	lr, or, _ := paneSizes{}.paneRects(60, 12)
+	b.Key(keyEvent{key: keyTab})
+	vs := b.Type("e4")
+	if got := vs.Line(0); !strings.Contains(got, "List e4 (1/5)") {
+		t.Errorf("title: got %q", got)
+	}
+	if got := vs.Line(lr.y0 + 1); !strings.Contains(got, "Line 4") || strings.Contains(vs.Line(lr.y0+2), "Line") {
+		t.Errorf("filtered list:\n%s", vs)
+	}
+	if _, st := vs.Cell(lr.x0+6, lr.y0+1); st != (style{fg: colorRed, reverse: true}) {
+		t.Errorf("match not highlighted: got %+v", st)
+	}
+	vs = b.Key(keyEvent{key: keyEnter})
+	if got := vs.Line(or.y0 + 2); !strings.Contains(got, "Line 4") {
+		t.Errorf("output line: got %q\n%s", got, vs)
+	}
+}
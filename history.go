@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The input pane remembers the lines that the user submitted. Up and
+// Down bring them back, and Ctrl-R searches them, like in a shell. The
+// history goes into a file, so that it survives a restart.
+
+// historyLimit is the number of lines that the history keeps.
+const historyLimit = 1000
+
+// historySearchKey starts a reverse search through the history, or
+// goes to the next older match during a search. In vi's normal mode,
+// it is vi's redo key instead.
+var historySearchKey = ctrl('r')
+
+// The history file keeps one entry per line. Multi-line entries have
+// their newlines escaped as \n, and thus backslashes as \\. The files
+// of earlier versions have no escapes, so a header line tells the
+// formats apart: files without it keep their lines as they are.
+const historyHeader = "# appliedgo-tui history v2"
+
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+// historyPath is the file that keeps the history. If it is empty, the
+// history is lost when the app quits. The -history flag changes it.
+var historyPath string
+
+// defaultHistoryPath returns the history file in the user's config
+// directory, or "" if there is no such directory.
+func defaultHistoryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "appliedgo-tui", "history")
+}
+
+// An inputHistory holds the submitted lines, oldest first, without
+// duplicates, and the state of browsing and searching them.
+type inputHistory struct {
+	entries []string
+	// path is the file that new entries are appended to, if any.
+	path string
+	// pos is the index of the entry in the input pane. At
+	// len(entries), the input pane shows draft, the line that the
+	// user was entering before going back in the history.
+	pos   int
+	draft string
+	// searching is set during a reverse search. query is the search
+	// text, and found the index of the matching entry, or -1. orig and
+	// origPos are the line and the position to return to if the user
+	// cancels the search.
+	searching bool
+	query     lineEditor
+	found     int
+	orig      string
+	origPos   int
+}
+
+// Load reads the history from the file at path and appends new entries
+// to it from now on. A missing file is an empty history. If the file
+// is in the format of an earlier version, or has grown much longer than
+// the history, Load writes it anew, and if that fails, it leaves the
+// file alone from now on. Lines can be of any length, as a submitted
+// paste can be long.
+func (h *inputHistory) Load(path string) error {
+	h.path = path
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Cannot open history file")
+	}
+	defer f.Close()
+	lines, escaped := 0, false
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+		case lines == 0 && !escaped && line == historyHeader:
+			escaped = true
+		case escaped:
+			h.add(historyUnescaper.Replace(line))
+			lines++
+		default:
+			h.add(line)
+			lines++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "Cannot read history file")
+		}
+	}
+	if escaped && lines <= 2*historyLimit {
+		return nil
+	}
+	err = h.save()
+	if err != nil {
+		// Appending escaped entries would garble an old file.
+		h.path = ""
+	}
+	return err
+}
+
+// save writes all entries to the history file. It writes a temporary
+// file first, so that a failure does not lose the old history.
+func (h *inputHistory) save() error {
+	tmp := h.path + ".tmp"
+	var sb strings.Builder
+	sb.WriteString(historyHeader + "\n")
+	for _, e := range h.entries {
+		sb.WriteString(historyEscaper.Replace(e) + "\n")
+	}
+	err := os.WriteFile(tmp, []byte(sb.String()), 0o600)
+	if err != nil {
+		return errors.Wrap(err, "Cannot write history file")
+	}
+	return errors.Wrap(os.Rename(tmp, h.path), "Cannot replace history file")
+}
+
+// add appends line to the entries and removes an older copy of it.
+func (h *inputHistory) add(line string) {
+	for i, e := range h.entries {
+		if e == line {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+	h.pos = len(h.entries)
+}
+
+// Add adds a submitted line to the history and to the history file,
+// and ends browsing and searching. Empty lines are not worth keeping.
+func (h *inputHistory) Add(line string) error {
+	h.searching = false
+	h.pos, h.draft = len(h.entries), ""
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	h.add(line)
+	if h.path == "" {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(h.path), 0o700)
+	if err != nil {
+		return errors.Wrap(err, "Cannot create history directory")
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "Cannot open history file")
+	}
+	text := historyEscaper.Replace(line) + "\n"
+	// A new file starts with the header.
+	if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+		text = historyHeader + "\n" + text
+	}
+	_, err = f.WriteString(text)
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "Cannot write history file")
+	}
+	return errors.Wrap(f.Close(), "Cannot close history file")
+}
+
+// show puts entry i, or the draft, into the line editor e.
+func (h *inputHistory) show(e *lineEditor, i int) {
+	h.pos = i
+	if i < len(h.entries) {
+		e.Set(h.entries[i])
+	} else {
+		e.Set(h.draft)
+	}
+}
+
+// find makes the newest entry at or before index i that contains the
+// query the found entry, and shows it in e. Without such an entry, e
+// keeps the last match.
+func (h *inputHistory) find(e *lineEditor, i int) {
+	q := h.query.String()
+	for ; i >= 0; i-- {
+		if i < len(h.entries) && strings.Contains(h.entries[i], q) {
+			h.found = i
+			h.show(e, i)
+			return
+		}
+	}
+	h.found = -1
+}
+
+// HandleKey applies a history key to the line editor e and reports
+// whether the key was one. Up and Down go through the history, and
+// historySearchKey starts a search. In a multi-line input, Up and Down
+// move between the lines first, and only go through the history from
+// the first or the last line. During a search, characters and
+// Backspace change the search text, Esc or Ctrl-G cancel the search,
+// and any other key ends the search and keeps the match in e.
+func (h *inputHistory) HandleKey(ev keyEvent, e *lineEditor) bool {
+	if h.searching {
+		switch {
+		case ev == historySearchKey:
+			if h.found >= 0 {
+				h.find(e, h.found-1)
+			}
+			return true
+		case stopKeys[ev]:
+			h.searching = false
+			h.pos = h.origPos
+			e.Set(h.orig)
+			return true
+		case ev.key == keyBackspace, ev.key == keyRune && ev.mod&(modCtrl|modAlt) == 0:
+			h.query.HandleKey(ev)
+			h.find(e, min(h.pos, len(h.entries)-1))
+			return true
+		}
+		h.searching = false
+	}
+	switch {
+	case ev.key == keyUp && ev.mod == 0:
+		if e.lineUp() {
+			break
+		}
+		if h.pos > 0 {
+			if h.pos == len(h.entries) {
+				h.draft = e.String()
+			}
+			h.show(e, h.pos-1)
+		}
+	case ev.key == keyDown && ev.mod == 0:
+		if e.lineDown() {
+			break
+		}
+		if h.pos < len(h.entries) {
+			h.show(e, h.pos+1)
+		}
+	case ev == historySearchKey && !e.normal:
+		h.searching, h.found = true, -1
+		h.orig, h.origPos = e.String(), h.pos
+		if h.pos == len(h.entries) {
+			h.draft = h.orig
+		}
+		h.query.Reset()
+	default:
+		return false
+	}
+	return true
+}
+
+// inputTitle returns the title of an input pane with the line editor e,
+// the history h, and the paste guard g. It shows the search text during
+// a history search and tells when vi is in normal mode. While a paste
+// waits for the confirmation, it asks for it.
+func inputTitle(e *lineEditor, h *inputHistory, g *pasteGuard) string {
+	if t := g.Title(); t != "" {
+		return t
+	}
+	t := h.Title()
+	if e.normal {
+		t += " [normal]"
+	}
+	return t
+}
+
+// Title returns the title of the input pane, which shows the search
+// text during a search.
+func (h *inputHistory) Title() string {
+	if !h.searching {
+		return "Input"
+	}
+	t := "Input search: " + h.query.String()
+	if h.found < 0 && h.query.String() != "" {
+		t += " (no match)"
+	}
+	return t
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputHistory(t *testing.T) {
+	var h inputHistory
+	var e lineEditor
+	for _, line := range []string{"make", "go test", "", "make", "go vet"} {
+		h.Add(line)
+	}
+	// The empty line is left out, and the older "make" is gone.
+	if got := strings.Join(h.entries, ","); got != "go test,make,go vet" {
+		t.Fatalf("entries: got %q", got)
+	}
+
+	e.Set("draft")
+	up, down := keyEvent{key: keyUp}, keyEvent{key: keyDown}
+	for _, want := range []string{"go vet", "make", "go test", "go test"} {
+		h.HandleKey(up, &e)
+		if e.String() != want {
+			t.Errorf("up: got %q, want %q", e.String(), want)
+		}
+	}
+	for _, want := range []string{"make", "go vet", "draft", "draft"} {
+		h.HandleKey(down, &e)
+		if e.String() != want {
+			t.Errorf("down: got %q, want %q", e.String(), want)
+		}
+	}
+
+	// Search for "go", then for the next older match.
+	h.HandleKey(historySearchKey, &e)
+	for _, ch := range "go" {
+		h.HandleKey(keyEvent{key: keyRune, ch: ch}, &e)
+	}
+	if e.String() != "go vet" || h.Title() != "Input search: go" {
+		t.Errorf("search: got %q, %q", e.String(), h.Title())
+	}
+	h.HandleKey(historySearchKey, &e)
+	if e.String() != "go test" {
+		t.Errorf("next match: got %q", e.String())
+	}
+	h.HandleKey(keyEvent{key: keyRune, ch: 'x'}, &e)
+	if e.String() != "go test" || h.Title() != "Input search: gox (no match)" {
+		t.Errorf("no match: got %q, %q", e.String(), h.Title())
+	}
+	// Cancelling restores the line from before the search.
+	h.HandleKey(ctrl('g'), &e)
+	if e.String() != "draft" || h.Title() != "Input" {
+		t.Errorf("cancel: got %q, %q", e.String(), h.Title())
+	}
+
+	// Any other key keeps the match, and Up goes on from there.
+	h.HandleKey(historySearchKey, &e)
+	h.HandleKey(keyEvent{key: keyRune, ch: 'm'}, &e)
+	if h.HandleKey(keyEvent{key: keyLeft}, &e) || e.String() != "make" {
+		t.Errorf("accept: got %q", e.String())
+	}
+	h.HandleKey(up, &e)
+	if e.String() != "go test" {
+		t.Errorf("up after search: got %q", e.String())
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history")
+	var h inputHistory
+	if err := h.Load(path); err != nil {
+		t.Fatal("missing file:", err)
+	}
+	for _, line := range []string{"a", "b", "a"} {
+		if err := h.Add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var h2 inputHistory
+	if err := h2.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(h2.entries, ","); got != "b,a" {
+		t.Errorf("loaded: got %q", got)
+	}
+
+	// A file with too many lines gets compacted.
+	var sb strings.Builder
+	sb.WriteString(historyHeader + "\n")
+	for range 2*historyLimit + 1 {
+		sb.WriteString("same\n")
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var h3 inputHistory
+	if err := h3.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != historyHeader+"\nsame\n" {
+		t.Errorf("compacted file: got %q", data)
+	}
+}
+
+// Files of earlier versions have no header and no escapes. Their
+// lines read as they are, and the file gets the current format.
+func TestHistoryOldFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	old := "one\n" + `not\na newline` + "\n" + `back\\slash` + "\n"
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var h inputHistory
+	if err := h.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"one", `not\na newline`, `back\\slash`}
+	if !reflect.DeepEqual(h.entries, want) {
+		t.Errorf("old file: got %q", h.entries)
+	}
+	if err := h.Add("two\nlines"); err != nil {
+		t.Fatal(err)
+	}
+	var h2 inputHistory
+	if err := h2.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if want := append(want, "two\nlines"); !reflect.DeepEqual(h2.entries, want) {
+		t.Errorf("converted file: got %q", h2.entries)
+	}
+}
+
+func TestHistoryMultiLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	var h inputHistory
+	if err := h.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	entries := []string{"one", "two\nlines", `back\slash`, `not\na newline`}
+	// A long paste does not break the history file.
+	long := strings.Repeat("x", 100000)
+	if err := h.Add(long); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range entries {
+		if err := h.Add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var h2 inputHistory
+	if err := h2.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h2.entries, append([]string{long}, entries...)) {
+		t.Errorf("loaded: got %q", h2.entries)
+	}
+
+	// Up and Down move between the lines of an entry before they go
+	// on through the history.
+	var e lineEditor
+	up, down := keyEvent{key: keyUp}, keyEvent{key: keyDown}
+	steps := []struct {
+		ev   keyEvent
+		want string
+		row  int
+	}{
+		{up, `not\na newline`, 0},
+		{up, `back\slash`, 0},
+		{up, "two\nlines", 1},
+		{up, "two\nlines", 0},
+		{up, "one", 0},
+		{down, "two\nlines", 1},
+		{down, `back\slash`, 0},
+	}
+	for _, st := range steps {
+		h2.HandleKey(st.ev, &e)
+		if row, _ := e.position(e.cursor); e.String() != st.want || row != st.row {
+			t.Errorf("got %q, line %d; want %q, line %d", e.String(), row, st.want, st.row)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import "strings"
+
+// Each TUI library has its own idea of key events. The backends that
+// leave the app logic to shared code translate their events into a
+// keyEvent first.
+
+// key identifies a non-printable key. Printable characters and
+// Ctrl/Alt combinations with a character use keyRune.
+type key int
+
+const (
+	keyRune key = iota
+	keyEnter
+	keyTab
+	keyBacktab
+	keyBackspace
+	keyDelete
+	keyEsc
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPgUp
+	keyPgDn
+	// keyPaste is text that the user pasted, see paste.go.
+	// keyPasteStart and keyPasteEnd only occur while decoding: they
+	// mark the start and the end of a paste.
+	keyPaste
+	keyPasteStart
+	keyPasteEnd
+	// keyOther stands for any key the app has no use for, like the
+	// function keys.
+	keyOther
+)
+
+// modifier is a bit set of modifier keys held down with a key.
+type modifier int
+
+const (
+	modCtrl modifier = 1 << iota
+	modAlt
+	modShift
+)
+
+// A keyEvent is a single key press. Ctrl-A, for example, is
+// keyEvent{key: keyRune, ch: 'a', mod: modCtrl}. A keyPaste event
+// carries the pasted text.
+type keyEvent struct {
+	key  key
+	ch   rune
+	mod  modifier
+	text string
+}
+
+// ctrl returns the keyEvent for Ctrl plus the given lowercase letter.
+func ctrl(ch rune) keyEvent {
+	return keyEvent{key: keyRune, ch: ch, mod: modCtrl}
+}
+
+// mouseButton tells what a mouseEvent is about.
+type mouseButton int
+
+const (
+	mouseLeft mouseButton = iota
+	mouseRelease
+	mouseWheelUp
+	mouseWheelDown
+	// mouseOther stands for the buttons the app has no use for.
+	mouseOther
+)
+
+// A mouseEvent is a mouse button or wheel event at the screen position
+// x, y. motion is set if the pointer moved with the button held down.
+type mouseEvent struct {
+	x, y   int
+	button mouseButton
+	motion bool
+}
+
+// wheelLines is the number of lines that one step of the mouse wheel
+// scrolls.
+const wheelLines = 3
+
+// termbox, and with it termui and gocui, does not know every escape
+// sequence that terminals send. An unknown sequence like Shift-Tab's
+// "\x1b[Z" arrives in pieces: as Alt-[ followed by plain characters,
+// as the backends run termbox in InputAlt mode. A seqDecoder puts such
+// sequences back together.
+type seqDecoder struct {
+	// prefix is the event that started the pending sequence, and
+	// pending collects the characters that follow as long as they
+	// might form a known sequence.
+	prefix  *keyEvent
+	pending []rune
+	// paste collects the keys of a bracketed paste.
+	paste pasteBuffer
+}
+
+// escSeqs maps the sequences that seqDecoder knows, without the leading
+// "\x1b[", to the keys they stand for.
+var escSeqs = map[string]keyEvent{
+	"Z": {key: keyBacktab},
+	// xterm sends the arrows with Ctrl held down as "1;5" plus the
+	// letter of the arrow.
+	"1;5A": {key: keyUp, mod: modCtrl},
+	"1;5B": {key: keyDown, mod: modCtrl},
+	"1;5C": {key: keyRight, mod: modCtrl},
+	"1;5D": {key: keyLeft, mod: modCtrl},
+	// Terminals that tell Enter with Shift or Ctrl apart from plain
+	// Enter send either xterm's "27;<mod>;13~" or "13;<mod>u".
+	"27;2;13~": {key: keyEnter, mod: modShift},
+	"27;5;13~": {key: keyEnter, mod: modCtrl},
+	"13;2u":    {key: keyEnter, mod: modShift},
+	"13;5u":    {key: keyEnter, mod: modCtrl},
+	// Bracketed paste mode wraps pasted text into these.
+	"200~": {key: keyPasteStart},
+	"201~": {key: keyPasteEnd},
+}
+
+var altBracket = keyEvent{key: keyRune, ch: '[', mod: modAlt}
+
+// Decode feeds ev into the decoder and returns the events that are
+// complete: none while a sequence or a paste is pending, the decoded
+// key at the end of a known sequence, a keyPaste event at the end of
+// a paste, and the original events otherwise.
+func (d *seqDecoder) Decode(ev keyEvent) []keyEvent {
+	var evs []keyEvent
+	for _, ev := range d.decode(ev) {
+		switch {
+		case ev.key == keyPasteStart:
+			d.paste.Start()
+		case ev.key == keyPasteEnd:
+			if p, ok := d.paste.End(); ok {
+				evs = append(evs, p)
+			}
+		case d.paste.Add(ev):
+		default:
+			evs = append(evs, ev)
+		}
+	}
+	return evs
+}
+
+// Pasting reports whether a paste is in progress.
+func (d *seqDecoder) Pasting() bool {
+	return d.paste.active
+}
+
+// decode puts the escape sequences back together for Decode.
+func (d *seqDecoder) decode(ev keyEvent) []keyEvent {
+	if d.prefix == nil {
+		if ev == altBracket {
+			d.prefix = &ev
+			d.pending = d.pending[:0]
+			return nil
+		}
+		return []keyEvent{ev}
+	}
+	if ev.key == keyRune && ev.mod == 0 {
+		d.pending = append(d.pending, ev.ch)
+		s := string(d.pending)
+		if k, ok := escSeqs[s]; ok {
+			d.prefix = nil
+			return []keyEvent{k}
+		}
+		for seq := range escSeqs {
+			if strings.HasPrefix(seq, s) {
+				return nil
+			}
+		}
+		return d.flush()
+	}
+	return append(d.flush(), ev)
+}
+
+// flush ends a sequence that turned out to be unknown, and returns the
+// events that were held back.
+func (d *seqDecoder) flush() []keyEvent {
+	evs := []keyEvent{*d.prefix}
+	for _, ch := range d.pending {
+		evs = append(evs, keyEvent{key: keyRune, ch: ch})
+	}
+	d.prefix = nil
+	return evs
+}
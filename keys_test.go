@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSeqDecoder(t *testing.T) {
+	r := func(s string) []keyEvent {
+		var evs []keyEvent
+		for _, ch := range s {
+			evs = append(evs, keyEvent{key: keyRune, ch: ch})
+		}
+		return evs
+	}
+	esc := keyEvent{key: keyEsc}
+	tests := []struct {
+		name string
+		in   []keyEvent
+		want []keyEvent
+	}{
+		{"plain", r("a[Z"), r("a[Z")},
+		{"alt", append([]keyEvent{altBracket}, r("Z")...), []keyEvent{{key: keyBacktab}}},
+		// termbox reports the Esc of a sequence as Alt, so a real Esc
+		// starts no sequence.
+		{"esc", append([]keyEvent{esc}, r("[Z")...), append([]keyEvent{esc}, r("[Z")...)},
+		{"unknown", append([]keyEvent{altBracket}, r("x")...), append([]keyEvent{altBracket}, r("x")...)},
+		{"ctrl arrow", append([]keyEvent{altBracket}, r("1;5D")...), []keyEvent{{key: keyLeft, mod: modCtrl}}},
+		{"interrupted", []keyEvent{altBracket, {key: keyEnter}}, []keyEvent{altBracket, {key: keyEnter}}},
+		// A bracketed paste arrives as one event, newlines included.
+		{"paste", slices.Concat([]keyEvent{altBracket}, r("200~a"), []keyEvent{{key: keyEnter}}, r("b"), []keyEvent{altBracket}, r("201~")),
+			[]keyEvent{{key: keyPaste, text: "a\nb"}}},
+		// Nothing but the paste comes out, even if the paste looks like
+		// a sequence itself.
+		{"paste only", slices.Concat([]keyEvent{altBracket}, r("200~[Z"), []keyEvent{altBracket}, r("201~x")),
+			[]keyEvent{{key: keyPaste, text: "[Z"}, r("x")[0]}},
+	}
+	for _, tt := range tests {
+		var d seqDecoder
+		var got []keyEvent
+		for _, ev := range tt.in {
+			got = append(got, d.Decode(ev)...)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// termui reports Shift-Tab, which termbox does not know, as "M-[" and
+// "Z". The decoder must turn it into Shift-Tab and nothing else, or a
+// stray Esc would turn the next key into an Alt key.
+func TestTermuiSeq(t *testing.T) {
+	var d seqDecoder
+	var got []keyEvent
+	for _, s := range []string{"a", "M-[", "Z", "<tab>", "b", "c"} {
+		got = append(got, d.Decode(termuiKey(s))...)
+	}
+	want := []keyEvent{{key: keyRune, ch: 'a'}, {key: keyBacktab}, {key: keyTab}, {key: keyRune, ch: 'b'}, {key: keyRune, ch: 'c'}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
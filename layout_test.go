@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	t "github.com/gizak/termui"
+	c "github.com/jroimartin/gocui"
+	"github.com/pkg/errors"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// The layouts are rendered for each of these terminal sizes.
+var goldenSizes = []struct{ w, h int }{
+	{80, 24},
+	{40, 12},
+	{120, 30},
+}
+
+// golden compares got against the golden file testdata/name.golden. With
+// -update, it rewrites the golden file instead.
+func golden(tt *testing.T, name, got string) {
+	tt.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		err := os.MkdirAll("testdata", 0o755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(got), 0o644)
+		}
+		if err != nil {
+			tt.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tt.Fatalf("%v (run 'go test -update' to create it)", err)
+	}
+	if got != string(want) {
+		tt.Errorf("%s does not match %s\n--- got:\n%s--- want:\n%s", name, path, got, want)
+	}
+}
+
+// fakeGui stands in for a *c.Gui. Instead of creating views, it draws
+// their frames onto a virtual screen, with the view name as the title.
+type fakeGui struct {
+	vs *virtualScreen
+}
+
+func (g fakeGui) Size() (int, int) {
+	return g.vs.Size()
+}
+
+func (g fakeGui) SetView(name string, x0, y0, x1, y1 int) (*c.View, error) {
+	if x0 >= x1 || y0 >= y1 {
+		return nil, errors.New("invalid dimensions")
+	}
+	drawFrame(g.vs, rect{x0, y0, x1, y1}, name, colorDefault, false)
+	return nil, nil
+}
+
+func TestGoldenGocuiLayout(tt *testing.T) {
+	for _, size := range goldenSizes {
+		vs := newVirtualScreen(size.w, size.h)
+		err := layoutViews(fakeGui{vs}, paneSizes{})
+		if err != nil {
+			tt.Fatal(err)
+		}
+		golden(tt, fmt.Sprintf("gocui_%dx%d", size.w, size.h), vs.String())
+	}
+}
+
+func TestGoldenTermuiGrid(tt *testing.T) {
+	for _, size := range goldenSizes {
+		b := &termuiBackend{}
+		for _, build := range []func() error{b.BuildList, b.BuildOutput, b.BuildInput} {
+			err := build()
+			if err != nil {
+				tt.Fatal(err)
+			}
+		}
+		b.SetListItems(listItems)
+		b.AppendOutput("Press Ctrl-C to quit\n")
+
+		grid := t.NewGrid(b.row())
+		b.align(grid, size.w, size.h)
+		buf := grid.Buffer()
+		vs := newVirtualScreen(size.w, size.h)
+		for p, cell := range buf.CellMap {
+			if p.In(buf.Area) {
+				vs.SetCell(p.X, p.Y, cell.Ch, style{})
+			}
+		}
+		golden(tt, fmt.Sprintf("termui_%dx%d", size.w, size.h), vs.String())
+	}
+}
+
+// The border of the list snaps to the nearest grid column, but leaves
+// the panes their minimum widths.
+func TestGridSpan(tt *testing.T) {
+	tests := []struct{ w, tw, want int }{
+		{15, 60, 3},
+		{18, 60, 4},
+		{2, 60, 2},
+		{58, 60, 9},
+	}
+	for _, test := range tests {
+		if got := gridSpan(test.w, test.tw); got != test.want {
+			tt.Errorf("gridSpan(%d, %d) = %d, want %d", test.w, test.tw, got, test.want)
+		}
+	}
+}
+
+func TestGoldenCells(tt *testing.T) {
+	for _, size := range goldenSizes {
+		b := startHeadless(tt, size.w, size.h)
+		golden(tt, fmt.Sprintf("cells_%dx%d", size.w, size.h), b.Screen().String())
+	}
+}
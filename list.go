@@ -0,0 +1,207 @@
+package main
+
+import "fmt"
+
+// A listModel holds the items of the list pane, the selected item, and
+// the part of the list that is scrolled into view. The backends draw
+// the visible items and pass navigation keys to HandleKey. Typing
+// filters the items with fuzzyMatch.
+type listModel struct {
+	items []string
+	// query is the text that the user typed to filter the items, and
+	// view holds the indices of the items that match it, best match
+	// first. view is nil if there is no query.
+	query []rune
+	view  []int
+	// selected is an index into the filtered items.
+	selected int
+	// off is the index of the first visible item.
+	off int
+	// page is the number of visible items as of the last call to
+	// Visible. PgUp and PgDn move the selection by one page.
+	page int
+}
+
+// SetItems replaces the items, filters them with the current query,
+// and keeps the selection within bounds.
+func (l *listModel) SetItems(items []string) {
+	l.items = items
+	l.filter(nil)
+	l.Select(l.selected)
+}
+
+// count returns the number of items that pass the filter.
+func (l *listModel) count() int {
+	if l.view == nil {
+		return len(l.items)
+	}
+	return len(l.view)
+}
+
+// item returns the item at index i of the filtered items.
+func (l *listModel) item(i int) string {
+	if l.view == nil {
+		return l.items[i]
+	}
+	return l.items[l.view[i]]
+}
+
+// filter matches the items against the query. If candidates is not
+// nil, only the items with these indices can match. That saves time
+// when the user extends the query, because the new query can only
+// match items that the old one matched, too.
+func (l *listModel) filter(candidates []int) {
+	if len(l.query) == 0 {
+		l.view = nil
+		return
+	}
+	if candidates == nil {
+		candidates = make([]int, len(l.items))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+	l.view = fuzzyFilter(l.items, candidates, l.query)
+}
+
+// setQuery changes the query and selects the best match.
+func (l *listModel) setQuery(q []rune, candidates []int) {
+	l.query = q
+	l.filter(candidates)
+	l.selected, l.off = 0, 0
+}
+
+// Selected returns the selected item, or false if no item passes the
+// filter.
+func (l *listModel) Selected() (string, bool) {
+	if l.selected < 0 || l.selected >= l.count() {
+		return "", false
+	}
+	return l.item(l.selected), true
+}
+
+// Select selects the item at index i of the filtered items, clamped
+// to the list.
+func (l *listModel) Select(i int) {
+	if i >= l.count() {
+		i = l.count() - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	l.selected = i
+}
+
+// SelectVisible selects the item in row i of the visible part of the
+// list, if there is one.
+func (l *listModel) SelectVisible(i int) {
+	if i >= 0 && i < l.page && l.off+i < l.count() {
+		l.Select(l.off + i)
+	}
+}
+
+// Move moves the selection by n items; negative values move up.
+func (l *listModel) Move(n int) {
+	l.Select(l.selected + n)
+}
+
+// Visible returns the filtered items that fit into a pane of height h,
+// and the index of the selected item within them, or -1 if there are
+// none.
+// The visible part scrolls to keep the selection in view.
+func (l *listModel) Visible(h int) (items []string, sel int) {
+	if h < 1 {
+		h = 1
+	}
+	l.page = h
+	if l.selected < l.off {
+		l.off = l.selected
+	}
+	if l.selected >= l.off+h {
+		l.off = l.selected - h + 1
+	}
+	// Don't leave empty rows below the last item when the list
+	// shrinks or the pane grows.
+	n := l.count()
+	if l.off > n-h {
+		l.off = n - h
+	}
+	if l.off < 0 {
+		l.off = 0
+	}
+	for i := l.off; i < n && i < l.off+h; i++ {
+		items = append(items, l.item(i))
+	}
+	if n == 0 {
+		return items, -1
+	}
+	return items, l.selected - l.off
+}
+
+// HandleKey applies a navigation key or a filter key to the list and
+// reports whether the key was one of those. Characters extend the
+// query, Backspace takes back the last one, and Esc or Ctrl-G clear
+// the query.
+func (l *listModel) HandleKey(ev keyEvent) bool {
+	switch {
+	case ev.key == keyRune && ev.mod&(modCtrl|modAlt) == 0:
+		l.setQuery(append(l.query[:len(l.query):len(l.query)], ev.ch), l.view)
+		return true
+	case ev.key == keyBackspace && len(l.query) > 0:
+		l.setQuery(l.query[:len(l.query)-1], nil)
+		return true
+	case stopKeys[ev] && len(l.query) > 0:
+		l.setQuery(nil, nil)
+		return true
+	}
+
+	page := l.page
+	if page < 1 {
+		page = 1
+	}
+	switch ev.key {
+	case keyUp:
+		l.Move(-1)
+	case keyDown:
+		l.Move(1)
+	case keyPgUp:
+		l.Move(-page)
+	case keyPgDn:
+		l.Move(page)
+	case keyHome:
+		l.Select(0)
+	case keyEnd:
+		l.Select(l.count() - 1)
+	default:
+		return false
+	}
+	return true
+}
+
+// Spans returns the highlighted parts of row i of the visible items:
+// the characters that match the query.
+func (l *listModel) Spans(i int) []span {
+	n := l.off + i
+	if len(l.query) == 0 || n < 0 || n >= l.count() {
+		return nil
+	}
+	_, pos, _ := fuzzyMatch(l.item(n), l.query)
+	var spans []span
+	for _, p := range pos {
+		if k := len(spans) - 1; k >= 0 && spans[k].end == p {
+			spans[k].end++
+			continue
+		}
+		spans = append(spans, span{p, p + 1, fuzzyStyle})
+	}
+	return spans
+}
+
+// Title returns the title of the list pane: the number of items, and
+// the query with the number of items that match it.
+func (l *listModel) Title() string {
+	if len(l.query) == 0 {
+		return fmt.Sprintf("List (%d)", len(l.items))
+	}
+	return fmt.Sprintf("List %s (%d/%d)", string(l.query), len(l.view), len(l.items))
+}
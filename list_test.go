@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListModel(t *testing.T) {
+	var l listModel
+	l.SetItems([]string{"a", "b", "c", "d", "e", "f"})
+	l.Select(4)
+	items, sel := l.Visible(3)
+	if strings.Join(items, "") != "cde" || sel != 2 {
+		t.Errorf("got %q, %d", items, sel)
+	}
+	l.SetItems([]string{"a", "b"})
+	if item, ok := l.Selected(); !ok || item != "b" {
+		t.Errorf("selection not clamped: got %q", item)
+	}
+	l.SetItems(nil)
+	if _, ok := l.Selected(); ok {
+		t.Error("empty list has a selection")
+	}
+	if _, sel := l.Visible(3); sel != -1 {
+		t.Errorf("empty list: got selection %d", sel)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	var l listModel
+	l.SetItems([]string{"apple", "banana", "blueberry", "cherry"})
+	typeKeys := func(s string) {
+		for _, ch := range s {
+			l.HandleKey(keyEvent{key: keyRune, ch: ch})
+		}
+	}
+
+	typeKeys("br")
+	items, sel := l.Visible(3)
+	if strings.Join(items, ",") != "blueberry" || sel != 0 {
+		t.Errorf("got %q, %d", items, sel)
+	}
+	if got := l.Title(); got != "List br (1/4)" {
+		t.Errorf("title: got %q", got)
+	}
+	if got := l.Spans(0); len(got) != 2 || got[0] != (span{0, 1, fuzzyStyle}) || got[1] != (span{6, 7, fuzzyStyle}) {
+		t.Errorf("spans: got %v", got)
+	}
+
+	// Backspace widens the filter again.
+	l.HandleKey(keyEvent{key: keyBackspace})
+	l.HandleKey(keyEvent{key: keyDown})
+	if item, _ := l.Selected(); item != "blueberry" {
+		t.Errorf("after backspace: got %q selected", item)
+	}
+
+	// No match, then Esc clears the query.
+	typeKeys("x")
+	if _, ok := l.Selected(); ok || l.Title() != "List bx (0/4)" {
+		t.Errorf("no match: got %q", l.Title())
+	}
+	l.HandleKey(keyEvent{key: keyEsc})
+	if items, _ := l.Visible(5); len(items) != 4 || l.Title() != "List (4)" {
+		t.Errorf("query not cleared: got %q, %q", items, l.Title())
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An outputModel holds the lines of the output pane and how far the
+// pane is scrolled back. Like the list, the backends only draw the
+// visible lines.
+//
+// While the pane shows the end of the output, it follows new output.
+// Scrolling back pauses follow mode: new lines do not move the visible
+// part until the user scrolls back down to the end.
+type outputModel struct {
+	// lines holds the output. The last line is the one that Append
+	// continues.
+	lines []string
+	// scroll is the number of lines between the last visible line and
+	// the end of the output. Zero shows the most recent lines.
+	scroll int
+	// page is the number of visible lines as of the last call to
+	// Visible, and first the index of the first one. PgUp and PgDn
+	// scroll by one page.
+	page, first int
+	// search is the state of the search in the output (see search.go),
+	// and filter the state of the filter (see filter.go). Scrolling and
+	// searching only consider the lines that pass the filter.
+	search outputSearch
+	filter outputFilter
+}
+
+// Append adds s to the output. s may contain any number of newlines.
+func (o *outputModel) Append(s string) {
+	if len(o.lines) == 0 {
+		o.lines = []string{""}
+	}
+	n := len(o.shown())
+	parts := strings.Split(s, "\n")
+	o.lines[len(o.lines)-1] += parts[0]
+	o.lines = append(o.lines, parts[1:]...)
+	if !o.Following() {
+		o.scroll += len(o.shown()) - n
+	}
+}
+
+// Clear removes all output. A search or a filter stays active for
+// new output.
+func (o *outputModel) Clear() {
+	o.lines, o.scroll = nil, 0
+	o.filter.lines, o.filter.checked = nil, 0
+	o.search.found, o.search.origin = false, 0
+}
+
+// Following reports whether the output pane follows new output.
+func (o *outputModel) Following() bool {
+	return o.scroll == 0
+}
+
+// Title returns the title of the output pane. While follow mode is
+// paused, it tells how many lines there are below the visible ones.
+func (o *outputModel) Title() string {
+	t := "Output"
+	if !o.Following() {
+		t += fmt.Sprintf(" [+%d]", o.scroll)
+	}
+	return t + o.filterTitle() + o.searchTitle()
+}
+
+// Lines returns the output line by line. An unfinished last line
+// counts as a line, but the empty line after a final newline does not.
+func (o *outputModel) Lines() []string {
+	lines := o.lines
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+	return lines
+}
+
+// Scroll scrolls the output back by n lines, or forward if n is
+// negative.
+func (o *outputModel) Scroll(n int) {
+	o.scroll += n
+	if o.scroll < 0 {
+		o.scroll = 0
+	}
+}
+
+// Visible returns the lines that fit into a pane of height h.
+func (o *outputModel) Visible(h int) []string {
+	lines := o.shown()
+	if h < 0 {
+		h = 0
+	}
+	o.page = h
+	// Don't scroll back further than to the first line.
+	if limit := len(lines) - h; o.scroll > limit {
+		o.scroll = limit
+	}
+	if o.scroll < 0 {
+		o.scroll = 0
+	}
+	end := len(lines) - o.scroll
+	start := end - h
+	if start < 0 {
+		start = 0
+	}
+	o.first = start
+	return lines[start:end]
+}
+
+// HandleKey applies a scroll or search key to the output and reports
+// whether the key had any effect. Home scrolls to the first line, End
+// back to the end, which resumes follow mode.
+func (o *outputModel) HandleKey(ev keyEvent) bool {
+	switch {
+	case o.search.prompt:
+		o.handleSearchKey(ev)
+		return true
+	case o.filter.prompt:
+		o.handleFilterKey(ev)
+		return true
+	}
+	switch {
+	case ev == keyEvent{key: keyRune, ch: '/'}, ev == keyEvent{key: keyRune, ch: '?'}:
+		o.startSearch(ev.ch == '?')
+		return true
+	case ev == keyEvent{key: keyRune, ch: 'n'}, ev == keyEvent{key: keyRune, ch: 'N'}:
+		if !o.search.active {
+			return false
+		}
+		o.next((ev.ch == 'n') != o.search.backward)
+		return true
+	case ev == keyEvent{key: keyRune, ch: '&'}:
+		o.startFilter()
+		return true
+	case stopKeys[ev] && o.search.active:
+		o.stopSearch()
+		return true
+	case stopKeys[ev] && o.filter.active():
+		o.handleFilterKey(ev)
+		return true
+	}
+	page := o.page
+	if page < 1 {
+		page = 1
+	}
+	switch ev.key {
+	case keyUp:
+		o.Scroll(1)
+	case keyDown:
+		o.Scroll(-1)
+	case keyPgUp:
+		o.Scroll(page)
+	case keyPgDn:
+		o.Scroll(-page)
+	case keyHome:
+		// Visible stops at the first line.
+		o.scroll = len(o.shown())
+	case keyEnd:
+		o.scroll = 0
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOutputModel(t *testing.T) {
+	var o outputModel
+	o.Append("a\nb")
+	o.Append("c\nd\ne\n")
+	if got := strings.Join(o.Lines(), ","); got != "a,bc,d,e" {
+		t.Errorf("Lines: got %q", got)
+	}
+	if got := strings.Join(o.Visible(2), ","); got != "d,e" {
+		t.Errorf("Visible: got %q", got)
+	}
+	o.Scroll(1)
+	if got := strings.Join(o.Visible(2), ","); got != "bc,d" {
+		t.Errorf("scrolled back: got %q", got)
+	}
+	// Scrolling stops at the first line.
+	o.Scroll(10)
+	if got := strings.Join(o.Visible(2), ","); got != "a,bc" {
+		t.Errorf("scrolled to the top: got %q", got)
+	}
+	o.Scroll(-10)
+	if got := strings.Join(o.Visible(2), ","); got != "d,e" {
+		t.Errorf("scrolled to the end: got %q", got)
+	}
+}
+
+func TestOutputFollow(t *testing.T) {
+	var o outputModel
+	for i := 0; i < 10; i++ {
+		o.Append(fmt.Sprintln(i))
+	}
+	o.Visible(3)
+	if !o.HandleKey(keyEvent{key: keyPgUp}) || o.Following() {
+		t.Fatal("PgUp did not pause follow mode")
+	}
+	if got := strings.Join(o.Visible(3), ","); got != "4,5,6" {
+		t.Errorf("page up: got %q", got)
+	}
+	// New output does not move the visible lines while paused.
+	o.Append("10\n11\n")
+	if got := strings.Join(o.Visible(3), ","); got != "4,5,6" {
+		t.Errorf("paused: got %q", got)
+	}
+	if got := o.Title(); got != "Output [+5]" {
+		t.Errorf("title: got %q", got)
+	}
+	o.HandleKey(keyEvent{key: keyHome})
+	if got := strings.Join(o.Visible(3), ","); got != "0,1,2" {
+		t.Errorf("home: got %q", got)
+	}
+	// Scrolling down to the end resumes follow mode.
+	for i := 0; i < 5; i++ {
+		o.HandleKey(keyEvent{key: keyPgDn})
+	}
+	o.Append("12\n")
+	if got := strings.Join(o.Visible(3), ","); got != "10,11,12" || o.Title() != "Output" {
+		t.Errorf("following: got %q, %q", got, o.Title())
+	}
+}
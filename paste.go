@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Without help, a terminal cannot tell pasted text from typed text, so
+// each newline of a multi-line paste would hit Enter and submit a
+// line. In bracketed paste mode, the terminal wraps pasted text into
+// "\x1b[200~" and "\x1b[201~". tcell reports these as events of their
+// own, and seqDecoder recognizes them for the termbox-based backends.
+// Either way, the keys between them end up in a pasteBuffer, and the
+// app receives the text as a single keyPaste event, which goes into
+// the input pane as a whole.
+
+// The sequences that turn bracketed paste mode on and off.
+const (
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+)
+
+// setBracketedPaste turns bracketed paste mode on or off. termbox, and
+// with it termui and gocui, knows nothing about this mode, so the
+// backends send the sequences to the terminal themselves. Like termbox,
+// they go to /dev/tty rather than to stdout, which may be redirected.
+func setBracketedPaste(on bool) {
+	seq := bracketedPasteOff
+	if on {
+		seq = bracketedPasteOn
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		// Without a terminal device, as on Windows, there is no
+		// bracketed paste mode to turn on.
+		return
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, seq)
+}
+
+// A pasteBuffer collects the keys of a bracketed paste.
+type pasteBuffer struct {
+	active bool
+	text   []rune
+}
+
+// Start starts collecting a paste.
+func (p *pasteBuffer) Start() {
+	p.active = true
+	p.text = p.text[:0]
+}
+
+// Add adds the character of ev to the paste and reports whether a paste
+// is in progress. Terminals send newlines as Enter, or sometimes as
+// Ctrl-J. Other keys, which are not part of any text, are dropped.
+func (p *pasteBuffer) Add(ev keyEvent) bool {
+	if !p.active {
+		return false
+	}
+	switch {
+	case ev.key == keyRune && ev.mod&(modCtrl|modAlt) == 0:
+		p.text = append(p.text, ev.ch)
+	case ev.key == keyEnter, ev == ctrl('j'):
+		p.text = append(p.text, '\n')
+	case ev.key == keyTab:
+		p.text = append(p.text, '\t')
+	}
+	return true
+}
+
+// End ends the paste and returns it as a keyPaste event. ok is false if
+// no paste was in progress.
+func (p *pasteBuffer) End() (ev keyEvent, ok bool) {
+	if !p.active {
+		return ev, false
+	}
+	p.active = false
+	return keyEvent{key: keyPaste, text: string(p.text)}, true
+}
+
+// pasteConfirmLines is the number of lines above which a paste waits
+// until the user confirms it, or 0 to insert every paste right away.
+// The -pasteconfirm flag sets it.
+var pasteConfirmLines int
+
+// pasteLines returns the number of lines of s. A newline at the end
+// does not start another line.
+func pasteLines(s string) int {
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
+
+// A pasteGuard inserts pastes into the input pane, but holds back a
+// paste of more than pasteConfirmLines lines until the user confirms
+// it.
+type pasteGuard struct {
+	// pending is the paste that waits for the confirmation.
+	pending string
+}
+
+// HandleKey inserts the paste ev into e, or holds it back, and reports
+// whether ev was a paste or the answer to the question whether to
+// insert a pending paste. y or Enter insert it, and n, Esc, or Ctrl-G
+// drop it. Until then, any other key is ignored.
+func (g *pasteGuard) HandleKey(ev keyEvent, e *lineEditor) bool {
+	switch {
+	case ev.key == keyPaste:
+		if pasteConfirmLines > 0 && pasteLines(ev.text) > pasteConfirmLines {
+			g.pending = ev.text
+		} else {
+			e.Paste(ev.text)
+		}
+		return true
+	case g.pending == "":
+		return false
+	case ev == keyEvent{key: keyRune, ch: 'y'}, ev == keyEvent{key: keyEnter}:
+		e.Paste(g.pending)
+	case ev == keyEvent{key: keyRune, ch: 'n'}, stopKeys[ev]:
+	default:
+		return true
+	}
+	g.pending = ""
+	return true
+}
+
+// Title returns the title of the input pane while a paste waits for
+// the confirmation, or "" otherwise.
+func (g *pasteGuard) Title() string {
+	if g.pending == "" {
+		return ""
+	}
+	return fmt.Sprintf("Input: paste %d lines? (y/n)", pasteLines(g.pending))
+}
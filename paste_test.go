@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestPasteGuard(t *testing.T) {
+	defer func(n int) { pasteConfirmLines = n }(pasteConfirmLines)
+	pasteConfirmLines = 2
+	paste := func(s string) keyEvent { return keyEvent{key: keyPaste, text: s} }
+	tests := []struct {
+		name  string
+		keys  []keyEvent
+		want  string
+		title string
+	}{
+		{"short", []keyEvent{paste("a\tb\nc\n")}, "a    b\nc\n", ""},
+		{"pending", []keyEvent{paste("a\nb\nc"), {key: keyRune, ch: 'x'}}, "", "Input: paste 3 lines? (y/n)"},
+		{"yes", []keyEvent{paste("a\nb\nc"), {key: keyRune, ch: 'x'}, {key: keyRune, ch: 'y'}}, "a\nb\nc", ""},
+		{"enter", []keyEvent{paste("a\nb\nc"), {key: keyEnter}}, "a\nb\nc", ""},
+		{"no", []keyEvent{paste("a\nb\nc"), {key: keyRune, ch: 'n'}}, "", ""},
+		{"esc", []keyEvent{paste("a\nb\nc"), {key: keyEsc}}, "", ""},
+	}
+	for _, tt := range tests {
+		var e lineEditor
+		var g pasteGuard
+		for _, ev := range tt.keys {
+			if !g.HandleKey(ev, &e) {
+				t.Errorf("%s: %v not handled", tt.name, ev)
+			}
+		}
+		if e.String() != tt.want || g.Title() != tt.title {
+			t.Errorf("%s: got %q, title %q; want %q, title %q", tt.name, e.String(), g.Title(), tt.want, tt.title)
+		}
+		if tt.title == "" && g.HandleKey(keyEvent{key: keyRune, ch: 'z'}, &e) {
+			t.Errorf("%s: key handled after the paste", tt.name)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// The output pane can search its lines, much like less or vi: "/"
+// searches forward and "?" backward, the pattern is matched while the
+// user types it, and n and N go to the next and the previous match.
+
+// Keys that toggle the pattern options while the user enters a
+// pattern, and keys that cancel the search. The option keys are those
+// of Emacs's incremental search. Ctrl-S would stop the output of
+// terminals with flow control, and Ctrl-R searches the history in the
+// input pane. A lone Esc gets stuck in termbox's InputAlt mode until
+// the next key arrives, so Ctrl-G cancels, too.
+var (
+	regexKey = keyEvent{key: keyRune, ch: 'r', mod: modAlt}
+	caseKey  = keyEvent{key: keyRune, ch: 'c', mod: modAlt}
+	stopKeys = map[keyEvent]bool{{key: keyEsc}: true, ctrl('g'): true}
+)
+
+// A pattern is a search pattern that the user enters, with its
+// options. By default, the pattern is a literal string that matches
+// regardless of case.
+type pattern struct {
+	text             lineEditor
+	regex, matchCase bool
+	// re is the compiled pattern, or nil if there is no pattern or
+	// the pattern is not a valid regular expression.
+	re *regexp.Regexp
+}
+
+// HandleKey applies an editing key or an option key to the pattern,
+// recompiles it, and reports whether the key was one of those keys.
+func (p *pattern) HandleKey(ev keyEvent) bool {
+	switch {
+	case ev == regexKey:
+		p.regex = !p.regex
+	case ev == caseKey:
+		p.matchCase = !p.matchCase
+	case !p.text.HandleKey(ev):
+		return false
+	}
+	p.compile()
+	return true
+}
+
+// Reset clears the pattern but keeps the options.
+func (p *pattern) Reset() {
+	p.text.Reset()
+	p.re = nil
+}
+
+// compile compiles the pattern with the current options.
+func (p *pattern) compile() {
+	p.re = nil
+	expr := p.text.String()
+	if expr == "" {
+		return
+	}
+	if !p.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !p.matchCase {
+		expr = "(?i)" + expr
+	}
+	p.re, _ = regexp.Compile(expr)
+}
+
+// String returns the pattern with its options for a title, and tells
+// if the pattern is not valid.
+func (p *pattern) String() string {
+	s := p.text.String()
+	if p.regex {
+		s += " [regex]"
+	}
+	if p.matchCase {
+		s += " [case]"
+	}
+	if p.text.String() != "" && p.re == nil {
+		s += " (bad pattern)"
+	}
+	return s
+}
+
+// An outputSearch is the state of a search in the output pane.
+type outputSearch struct {
+	pattern pattern
+	// prompt is set while the user enters the pattern, and active
+	// while the matches are highlighted.
+	prompt, active bool
+	// backward is set for a search with "?". It reverses n and N.
+	backward bool
+	// cur is the current match, if found is set.
+	cur   searchMatch
+	found bool
+	// origin is the line where the search starts, and scroll is the
+	// scroll position to return to if the user cancels the search.
+	origin, scroll int
+}
+
+// A searchMatch is a match in line number line, from the rune at index
+// start up to, but not including, the rune at index end.
+type searchMatch struct {
+	line, start, end int
+}
+
+// before reports whether m comes before n in the output.
+func (m searchMatch) before(n searchMatch) bool {
+	return m.line < n.line || m.line == n.line && m.start < n.start
+}
+
+// startSearch opens the search prompt. A forward search starts at the
+// first visible line, a backward search at the last one.
+func (o *outputModel) startSearch(backward bool) {
+	s := &o.search
+	s.pattern.Reset()
+	s.prompt, s.active, s.backward = true, true, backward
+	s.found = false
+	s.scroll = o.scroll
+	s.origin = o.first
+	if backward {
+		s.origin = o.first + o.page - 1
+	}
+}
+
+// stopSearch turns off the highlights. If the user is still entering
+// the pattern, the output scrolls back to where the search started.
+func (o *outputModel) stopSearch() {
+	if o.search.prompt {
+		o.scroll = o.search.scroll
+	}
+	o.search.prompt, o.search.active = false, false
+}
+
+// lineMatches returns the matches in line number i. Empty matches,
+// like those of "x*", cannot be highlighted and are left out.
+func (o *outputModel) lineMatches(i int, line string) []searchMatch {
+	re := o.search.pattern.re
+	if re == nil {
+		return nil
+	}
+	var ms []searchMatch
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		start := utf8.RuneCountInString(line[:loc[0]])
+		ms = append(ms, searchMatch{i, start, start + utf8.RuneCountInString(line[loc[0]:loc[1]])})
+	}
+	return ms
+}
+
+// matches returns all matches in the output, in order.
+func (o *outputModel) matches() []searchMatch {
+	var ms []searchMatch
+	for i, line := range o.shown() {
+		ms = append(ms, o.lineMatches(i, line)...)
+	}
+	return ms
+}
+
+// find makes the first match at or after line origin the current
+// match, or, for a backward search, the last match at or before it.
+// Without such a match, it wraps around.
+func (o *outputModel) find() {
+	s := &o.search
+	ms := o.matches()
+	s.found = len(ms) > 0
+	if !s.found {
+		return
+	}
+	s.cur = ms[0]
+	if s.backward {
+		s.cur = ms[len(ms)-1]
+	}
+	for i := range ms {
+		m := ms[i]
+		if s.backward {
+			m = ms[len(ms)-1-i]
+		}
+		if !s.backward && m.line >= s.origin || s.backward && m.line <= s.origin {
+			s.cur = m
+			break
+		}
+	}
+	o.scrollTo(s.cur.line)
+}
+
+// refind runs the search again after the shown lines have changed, as
+// cur would point into the old ones. Like a new search, it starts at
+// the first visible line, or at the last one for a backward search.
+func (o *outputModel) refind() {
+	s := &o.search
+	s.found = false
+	if !s.active {
+		return
+	}
+	o.first = max(len(o.shown())-o.scroll-o.page, 0)
+	s.origin = o.first
+	if s.backward {
+		s.origin = o.first + o.page - 1
+	}
+	o.find()
+}
+
+// next moves to the next match in the output, or to the previous one
+// if forward is false. At either end, it wraps around.
+func (o *outputModel) next(forward bool) {
+	s := &o.search
+	ms := o.matches()
+	s.found = len(ms) > 0
+	if !s.found {
+		return
+	}
+	i := 0
+	for i < len(ms) && ms[i].before(s.cur) {
+		i++
+	}
+	// ms[i] is the first match that does not come before cur, which
+	// usually is cur itself.
+	switch {
+	case forward && i < len(ms) && ms[i] == s.cur:
+		i++
+	case !forward:
+		i--
+	}
+	i = (i + len(ms)) % len(ms)
+	s.cur = ms[i]
+	o.scrollTo(s.cur.line)
+}
+
+// scrollTo scrolls line number i into the middle of the output pane,
+// unless it is visible already.
+func (o *outputModel) scrollTo(i int) {
+	if i >= o.first && i < o.first+o.page {
+		return
+	}
+	o.scroll = len(o.shown()) - 1 - i - o.page/2
+	if o.scroll < 0 {
+		o.scroll = 0
+	}
+}
+
+// handleSearchKey applies a key to the search prompt.
+func (o *outputModel) handleSearchKey(ev keyEvent) {
+	s := &o.search
+	switch {
+	case stopKeys[ev]:
+		o.stopSearch()
+		return
+	case ev.key == keyEnter:
+		s.prompt = false
+		s.active = s.pattern.re != nil
+	case s.pattern.HandleKey(ev):
+		o.find()
+	}
+}
+
+// Spans returns the highlighted parts of row i of the visible lines.
+func (o *outputModel) Spans(i int) []span {
+	if !o.search.active {
+		return nil
+	}
+	lines := o.shown()
+	n := o.first + i
+	if n < 0 || n >= len(lines) {
+		return nil
+	}
+	var spans []span
+	for _, m := range o.lineMatches(n, lines[n]) {
+		spans = append(spans, span{m.start, m.end, matchStyle(o.search.found && m == o.search.cur)})
+	}
+	return spans
+}
+
+// searchTitle returns the part of the title that describes the search:
+// the pattern with its options, and the position of the current match
+// among all matches.
+func (o *outputModel) searchTitle() string {
+	s := &o.search
+	if !s.active {
+		return ""
+	}
+	dir := "/"
+	if s.backward {
+		dir = "?"
+	}
+	t := " " + dir + s.pattern.String()
+	if s.pattern.re == nil {
+		return t
+	}
+	ms := o.matches()
+	for i, m := range ms {
+		if s.found && m == s.cur {
+			return t + fmt.Sprintf(" (%d/%d)", i+1, len(ms))
+		}
+	}
+	return t + " (no match)"
+}
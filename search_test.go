@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestOutputSearch(t *testing.T) {
+	var o outputModel
+	o.Append("one\ntwo\nthree\nTwo two\nfour\n")
+	o.Visible(2)
+	typeKeys := func(s string) {
+		for _, ch := range s {
+			o.HandleKey(keyEvent{key: keyRune, ch: ch})
+		}
+	}
+
+	// A forward search starts at the first visible line, "Two two",
+	// and finds matches regardless of case.
+	typeKeys("/tw")
+	if got := o.Title(); got != "Output /tw (2/3)" {
+		t.Errorf("title: got %q", got)
+	}
+	if got := o.Spans(0); len(got) != 2 || got[0].st != matchStyle(true) || got[1] != (span{4, 6, matchStyle(false)}) {
+		t.Errorf("spans: got %v", got)
+	}
+	o.HandleKey(keyEvent{key: keyEnter})
+	o.HandleKey(keyEvent{key: keyRune, ch: 'n'})
+	o.HandleKey(keyEvent{key: keyRune, ch: 'n'})
+	if o.search.cur != (searchMatch{1, 0, 2}) {
+		t.Errorf("n wraps around: got %v", o.search.cur)
+	}
+	// The match is scrolled into view.
+	if got := o.Visible(2); got[0] != "two" || got[1] != "three" {
+		t.Errorf("visible: got %q", got)
+	}
+	o.HandleKey(keyEvent{key: keyRune, ch: 'N'})
+	if o.search.cur != (searchMatch{3, 4, 6}) {
+		t.Errorf("N: got %v", o.search.cur)
+	}
+	o.Visible(2)
+
+	// A backward search starts at the last visible line, here with
+	// a case-sensitive regex.
+	typeKeys("?")
+	o.HandleKey(regexKey)
+	o.HandleKey(caseKey)
+	typeKeys("t[wh]")
+	if got := o.Title(); got != "Output ?t[wh] [regex] [case] (3/3)" {
+		t.Errorf("title: got %q", got)
+	}
+	typeKeys("(")
+	if got := o.Title(); got != "Output ?t[wh]( [regex] [case] (bad pattern)" {
+		t.Errorf("title: got %q", got)
+	}
+
+	// Cancelling the prompt returns to the previous scroll position
+	// and removes the highlights.
+	o.HandleKey(ctrl('g'))
+	if o.Spans(0) != nil || o.Title() != "Output" {
+		t.Errorf("search not cancelled: %q", o.Title())
+	}
+}
@@ -0,0 +1,373 @@
+//go:build !windows
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/hinshun/vt10x"
+	"github.com/pkg/errors"
+)
+
+// The sidebyside command runs two backends at once. Each one runs in
+// its own virtual terminal, and a tcell screen shows both virtual
+// screens next to each other. Keystrokes go to the focused half, or to
+// both halves in mirror mode, so that the two libraries can be watched
+// handling the very same input.
+//
+// The compositor claims three keys for itself:
+//
+//	F1   switch the focus to the other half
+//	F2   toggle mirror mode
+//	F10  quit both backends and the compositor
+
+func init() {
+	registerCommand("sidebyside", runSideBySide)
+}
+
+// A half is one side of the compositor's screen.
+type half struct {
+	name string
+	v    *vterm
+	// x0 is the screen column where the half starts.
+	x0 int
+	// buttons is the mouse button state last sent to the backend.
+	buttons tcell.ButtonMask
+}
+
+type compositor struct {
+	s      tcell.Screen
+	halves [2]*half
+	focus  int
+	mirror bool
+}
+
+// runSideBySide runs the backends given as arguments, or termui and
+// gocui by default.
+func runSideBySide(args []string) error {
+	fs := flag.NewFlagSet("sidebyside", flag.ContinueOnError)
+	mirror := fs.Bool("mirror", false, "start in mirror mode")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"termui", "gocui"}
+	}
+	if len(names) != 2 {
+		return errors.New("sidebyside needs two backends")
+	}
+
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return errors.Wrap(err, "Cannot create tcell screen")
+	}
+	err = s.Init()
+	if err != nil {
+		return errors.Wrap(err, "Cannot initialize tcell screen")
+	}
+	defer s.Fini()
+	s.EnableMouse()
+
+	cp := &compositor{s: s, mirror: *mirror}
+	for i, name := range names {
+		if _, ok := backends[name]; !ok {
+			return errors.New("No such backend: " + name)
+		}
+		cmd, err := selfCommand(name)
+		if err != nil {
+			return err
+		}
+		x0, w, h := cp.geometry(i)
+		v, err := startVterm(cmd, w, h)
+		if err != nil {
+			return err
+		}
+		defer v.Close()
+		cp.halves[i] = &half{name: name, v: v, x0: x0}
+	}
+	return cp.run()
+}
+
+// geometry returns the first column and the size of half i. A divider
+// column separates the halves, and a status line sits at the bottom.
+func (cp *compositor) geometry(i int) (x0, w, h int) {
+	tw, th := cp.s.Size()
+	left := (tw - 1) / 2
+	if i == 0 {
+		return 0, left, th - 1
+	}
+	return left + 1, tw - left - 1, th - 1
+}
+
+// run is the compositor's event loop. It redraws whenever the real
+// terminal or one of the virtual terminals has something new.
+func (cp *compositor) run() error {
+	events := make(chan tcell.Event)
+	go func() {
+		for {
+			ev := cp.s.PollEvent()
+			if ev == nil {
+				return
+			}
+			events <- ev
+		}
+	}()
+	// A closed done channel is always ready, so once a half has
+	// exited, the loop stops waiting for it. Otherwise, it would
+	// redraw in a busy loop.
+	done := [2]chan struct{}{cp.halves[0].v.done, cp.halves[1].v.done}
+	for {
+		if cp.halves[0].v.Exited() && cp.halves[1].v.Exited() {
+			return nil
+		}
+		cp.draw()
+		select {
+		case <-cp.halves[0].v.changed:
+		case <-cp.halves[1].v.changed:
+		case <-done[0]:
+			done[0] = nil
+		case <-done[1]:
+			done[1] = nil
+		case ev := <-events:
+			quit, err := cp.handle(ev)
+			if quit || err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle processes an event of the real terminal and reports whether
+// the compositor shall quit.
+func (cp *compositor) handle(ev tcell.Event) (quit bool, err error) {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		for i, hf := range cp.halves {
+			x0, w, h := cp.geometry(i)
+			hf.x0 = x0
+			err := hf.v.Resize(w, h)
+			if err != nil {
+				return false, errors.Wrap(err, "Cannot resize the virtual terminal of "+hf.name)
+			}
+		}
+		cp.s.Sync()
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyF1:
+			cp.focus = 1 - cp.focus
+			return false, nil
+		case tcell.KeyF2:
+			cp.mirror = !cp.mirror
+			return false, nil
+		case tcell.KeyF10:
+			return true, nil
+		}
+		for i, hf := range cp.halves {
+			if cp.mirror || i == cp.focus {
+				hf.v.Send(vtKey(ev, hf.v.Mode()))
+			}
+		}
+	case *tcell.EventMouse:
+		cp.mouse(ev)
+	}
+	return false, nil
+}
+
+// mouse routes a mouse event to the half under the pointer, or to both
+// halves in mirror mode. A click into a half also focuses it.
+func (cp *compositor) mouse(ev *tcell.EventMouse) {
+	x, y := ev.Position()
+	_, th := cp.s.Size()
+	if x == cp.halves[1].x0-1 || y >= th-1 {
+		// The divider and the status line belong to no half.
+		return
+	}
+	i := 0
+	if x >= cp.halves[1].x0 {
+		i = 1
+	}
+	if ev.Buttons()&tcell.Button1 != 0 {
+		cp.focus = i
+	}
+	for j, hf := range cp.halves {
+		if cp.mirror || j == i {
+			hf.v.Send(hf.mouse(ev.Buttons(), x-cp.halves[i].x0, y))
+		}
+	}
+}
+
+// mouse encodes a change of the mouse state in xterm's SGR format, if
+// the backend asked for mouse events at all.
+func (hf *half) mouse(buttons tcell.ButtonMask, x, y int) string {
+	mode := hf.v.Mode()
+	if mode&vt10x.ModeMouseMask == 0 {
+		return ""
+	}
+	pos := fmt.Sprintf("%d;%dM", x+1, y+1)
+	prev := hf.buttons
+	hf.buttons = buttons &^ (tcell.WheelUp | tcell.WheelDown)
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		return "\x1b[<64;" + pos
+	case buttons&tcell.WheelDown != 0:
+		return "\x1b[<65;" + pos
+	case buttons&tcell.Button1 != 0 && prev&tcell.Button1 == 0:
+		return "\x1b[<0;" + pos
+	case buttons&tcell.Button1 != 0 && mode&(vt10x.ModeMouseMotion|vt10x.ModeMouseMany) != 0:
+		return "\x1b[<32;" + pos
+	case buttons&tcell.Button1 == 0 && prev&tcell.Button1 != 0:
+		return "\x1b[<0;" + strings.TrimSuffix(pos, "M") + "m"
+	}
+	return ""
+}
+
+// draw copies both virtual screens onto the real one and adds the
+// divider and the status line.
+func (cp *compositor) draw() {
+	cp.s.Clear()
+	tw, th := cp.s.Size()
+	for i, hf := range cp.halves {
+		w, h := hf.v.Size()
+		hf.v.term.Lock()
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				g := hf.v.term.Cell(x, y)
+				cp.s.SetContent(hf.x0+x, y, g.Char, nil, vtStyle(g))
+			}
+		}
+		hf.v.term.Unlock()
+		if i == cp.focus {
+			cx, cy, visible := hf.v.Cursor()
+			if visible && !hf.v.Exited() {
+				cp.s.ShowCursor(hf.x0+cx, cy)
+			} else {
+				cp.s.HideCursor()
+			}
+		}
+	}
+	divider := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	for y := 0; y < th-1; y++ {
+		cp.s.SetContent(cp.halves[1].x0-1, y, '│', nil, divider)
+	}
+
+	mirror := "off"
+	if cp.mirror {
+		mirror = "on"
+	}
+	status := fmt.Sprintf(" F1 focus: %s | F2 mirror: %s | F10 quit", cp.halves[cp.focus].name, mirror)
+	for i, hf := range cp.halves {
+		if hf.v.Exited() {
+			status += fmt.Sprintf(" | %s has exited", cp.halves[i].name)
+		}
+	}
+	bar := tcell.StyleDefault.Reverse(true)
+	for x := 0; x < tw; x++ {
+		ch := ' '
+		if x < len([]rune(status)) {
+			ch = []rune(status)[x]
+		}
+		cp.s.SetContent(x, th-1, ch, nil, bar)
+	}
+	cp.s.Show()
+}
+
+// vtStyle converts the attributes of an emulated cell into a tcell
+// style.
+func vtStyle(g vt10x.Glyph) tcell.Style {
+	st := tcell.StyleDefault
+	if g.FG < 256 {
+		st = st.Foreground(tcell.PaletteColor(int(g.FG)))
+	}
+	if g.BG < 256 {
+		st = st.Background(tcell.PaletteColor(int(g.BG)))
+	}
+	return st.Reverse(g.Mode&vtReverse != 0).
+		Underline(g.Mode&vtUnderline != 0).
+		Bold(g.Mode&vtBold != 0)
+}
+
+var vtKeys = map[tcell.Key]string{
+	tcell.KeyEnter:      "\r",
+	tcell.KeyTab:        "\t",
+	tcell.KeyBacktab:    "\x1b[Z",
+	tcell.KeyBackspace:  "\x7f",
+	tcell.KeyBackspace2: "\x7f",
+	tcell.KeyEsc:        "\x1b",
+	tcell.KeyDelete:     "\x1b[3~",
+	tcell.KeyPgUp:       "\x1b[5~",
+	tcell.KeyPgDn:       "\x1b[6~",
+}
+
+// vtCursorKeys are sent with an "\x1bO" prefix if the backend switched
+// the terminal to application cursor keys, and with "\x1b[" otherwise.
+var vtCursorKeys = map[tcell.Key]string{
+	tcell.KeyUp:    "A",
+	tcell.KeyDown:  "B",
+	tcell.KeyRight: "C",
+	tcell.KeyLeft:  "D",
+	tcell.KeyHome:  "H",
+	tcell.KeyEnd:   "F",
+}
+
+// vtModifiers returns xterm's parameter for the modifiers of ev, which
+// is 1 plus a bit for each modifier, or 1 if there are none besides
+// Alt.
+func vtModifiers(ev *tcell.EventKey) int {
+	m := 1
+	if ev.Modifiers()&tcell.ModShift != 0 {
+		m += 1
+	}
+	if ev.Modifiers()&tcell.ModCtrl != 0 {
+		m += 4
+	}
+	if m > 1 && ev.Modifiers()&tcell.ModAlt != 0 {
+		m += 2
+	}
+	return m
+}
+
+// vtKey encodes a key event as the bytes that an xterm would send.
+// Like xterm, it adds the modifiers of the cursor keys with Ctrl and of
+// Enter as a parameter, so that the backends can resize panes with
+// Ctrl-arrows or insert a newline with Shift-Enter.
+func vtKey(ev *tcell.EventKey, mode vt10x.ModeFlag) string {
+	s := ""
+	m := vtModifiers(ev)
+	ctrl := ev.Modifiers()&tcell.ModCtrl != 0
+	if k, ok := vtCursorKeys[ev.Key()]; ok && ctrl {
+		return fmt.Sprintf("\x1b[1;%d%s", m, k)
+	}
+	if ev.Key() == tcell.KeyEnter && m > 1 {
+		return fmt.Sprintf("\x1b[27;%d;13~", m)
+	}
+	r := ev.Rune()
+	if k, ok := vtKeys[ev.Key()]; ok {
+		s = k
+	} else if k, ok := vtCursorKeys[ev.Key()]; ok {
+		if mode&vt10x.ModeAppCursor != 0 {
+			s = "\x1bO" + k
+		} else {
+			s = "\x1b[" + k
+		}
+	} else if ev.Key() >= tcell.KeyCtrlA && ev.Key() <= tcell.KeyCtrlZ {
+		s = string(rune(ev.Key()-tcell.KeyCtrlA) + 1)
+	} else if ev.Key() == tcell.KeyCtrlUnderscore || ev.Key() == tcell.KeyRune && ctrl && r == '/' {
+		// Ctrl-/ sends the same code as Ctrl-_.
+		s = "\x1f"
+	} else if ev.Key() == tcell.KeyRune && ctrl && r >= 'a' && r <= 'z' {
+		// tcell reports a control character after an Esc, like Alt-Enter,
+		// as a letter with Ctrl and Alt.
+		s = string(r - 'a' + 1)
+	} else if ev.Key() == tcell.KeyRune {
+		s = string(r)
+	}
+	if s != "" && ev.Modifiers()&tcell.ModAlt != 0 {
+		s = "\x1b" + s
+	}
+	return s
+}
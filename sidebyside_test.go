@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestVtKey(t *testing.T) {
+	tests := []struct {
+		ev   *tcell.EventKey
+		want string
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), "x"},
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), "\x1bx"},
+		{tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl), "\x01"},
+		{tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), "\x1b[A"},
+		{tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModCtrl), "\x1b[1;5A"},
+		{tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl), "\x1b[1;5D"},
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl), "\x1b[27;5;13~"},
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModShift), "\x1b[27;2;13~"},
+		{tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModCtrl|tcell.ModAlt), "\x1b\r"},
+		{tcell.NewEventKey(tcell.KeyCtrlUnderscore, 0, tcell.ModCtrl), "\x1f"},
+		{tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModCtrl), "\x1f"},
+	}
+	for _, tt := range tests {
+		if got := vtKey(tt.ev, 0); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.ev.Name(), got, tt.want)
+		}
+	}
+}
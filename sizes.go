@@ -0,0 +1,159 @@
+package main
+
+// The panes do not shrink below these sizes, as long as the terminal
+// is large enough. All sizes include the borders.
+const (
+	minListWidth    = 8
+	minOutputWidth  = 12
+	minOutputHeight = 3
+	minInputHeight  = 3
+)
+
+// maxInputHeight is the height, borders included, up to which the
+// input pane grows to fit a multi-line input. The -maxinput flag
+// changes it.
+var maxInputHeight = 10
+
+// A splitter is a border between panes that the user can drag with
+// the mouse.
+type splitter int
+
+const (
+	noSplitter splitter = iota
+	// listSplitter is the vertical border right of the list pane.
+	listSplitter
+	// inputSplitter is the horizontal border above the input pane.
+	inputSplitter
+)
+
+// paneSizes holds the width of the list pane and the height of the
+// input pane, which the user can change by dragging the splitters or
+// with resizeKeys. It stores them as differences to lw and ih, so that
+// the zero value is the initial layout.
+type paneSizes struct {
+	dw, dh int
+	// inputLines is the number of lines of the input. The backends
+	// update it before each layout.
+	inputLines int
+	// zoom is set while the focused pane covers the whole terminal.
+	zoom bool
+	// drag is the splitter that is being dragged, and grab is the
+	// distance of the pointer from the splitter when the drag started.
+	drag splitter
+	grab int
+}
+
+// size returns the list width and the input height, like lw and ih,
+// for a terminal of the size tw x th. The input pane grows to fit the
+// input's lines, up to maxInputHeight, but never shrinks below the
+// height that the user set.
+func (s paneSizes) size(tw, th int) (w, h int) {
+	return clampSize(lw+s.dw, max(ih+s.dh, min(s.inputLines+2, maxInputHeight)), tw, th)
+}
+
+// clampSize clamps the list width w and the input height h. The output
+// pane gets the rest, so each size leaves the other panes their
+// minimum size.
+func clampSize(w, h, tw, th int) (int, int) {
+	w = max(minListWidth-1, min(w, tw-1-minOutputWidth))
+	h = max(minInputHeight, min(h, th-minOutputHeight))
+	return w, h
+}
+
+// set changes the list width and the input height to w and h, within
+// the limits of the terminal.
+func (s *paneSizes) set(w, h, tw, th int) {
+	w, h = clampSize(w, h, tw, th)
+	s.dw, s.dh = w-lw, h-ih
+}
+
+// setListWidth sets the list width to w, for a backend that cannot put
+// the border of the list at any column, like termui.
+func (s *paneSizes) setListWidth(w int) {
+	s.dw = w - lw
+}
+
+// Resize grows the list pane by dw columns and the input pane by dh
+// rows, or shrinks them for negative values. The input pane resizes
+// from the height it has, which is more than the user set while it
+// fits a multi-line input, but resizing the list pane leaves the input
+// pane's setting alone.
+func (s *paneSizes) Resize(dw, dh, tw, th int) {
+	w, h := s.size(tw, th)
+	if dh == 0 {
+		h = ih + s.dh
+	}
+	s.set(w+dw, h+dh, tw, th)
+}
+
+// ToggleZoom maximizes the focused pane, or restores the layout if a
+// pane is maximized already.
+func (s *paneSizes) ToggleZoom() {
+	s.zoom = !s.zoom
+}
+
+// frames returns the frames of the visible panes when pane focus has
+// the focus: all of them, or only the focused one while zoomed.
+func (s paneSizes) frames(tw, th int, focus pane) map[pane]rect {
+	if s.zoom {
+		return map[pane]rect{focus: {0, 0, tw - 1, th - 1}}
+	}
+	lr, or, ir := s.paneRects(tw, th)
+	return map[pane]rect{paneList: lr, paneOutput: or, paneInput: ir}
+}
+
+// StartDrag starts dragging the splitter at the screen position x, y
+// and reports whether there is one. Each splitter is two cells thick:
+// the borders of the panes on both sides. While a pane is zoomed,
+// there are no splitters.
+func (s *paneSizes) StartDrag(x, y, tw, th int) bool {
+	w, h := s.size(tw, th)
+	top := th - h
+	switch {
+	case s.zoom:
+		s.drag = noSplitter
+	case x == w || x == w+1:
+		s.drag, s.grab = listSplitter, x-w
+	case x > w && (y == top || y == top-1):
+		s.drag, s.grab = inputSplitter, top-y
+	default:
+		s.drag = noSplitter
+	}
+	return s.drag != noSplitter
+}
+
+// Drag moves the dragged splitter to the pointer at x, y and reports
+// whether a drag is in progress.
+func (s *paneSizes) Drag(x, y, tw, th int) bool {
+	w, h := s.size(tw, th)
+	switch s.drag {
+	case listSplitter:
+		w, h = x-s.grab, ih+s.dh
+	case inputSplitter:
+		h = th - y - s.grab
+	default:
+		return false
+	}
+	s.set(w, h, tw, th)
+	return true
+}
+
+// EndDrag stops dragging and reports whether a drag was in progress.
+func (s *paneSizes) EndDrag() bool {
+	dragging := s.drag != noSplitter
+	s.drag = noSplitter
+	return dragging
+}
+
+// resizeKeys maps the keys that resize the panes to the number of
+// columns that the list pane grows and the number of rows that the
+// input pane grows. The arrows point where the border moves.
+var resizeKeys = map[keyEvent]struct{ dw, dh int }{
+	{key: keyLeft, mod: modCtrl}:  {-1, 0},
+	{key: keyRight, mod: modCtrl}: {1, 0},
+	{key: keyUp, mod: modCtrl}:    {0, 1},
+	{key: keyDown, mod: modCtrl}:  {0, -1},
+}
+
+// zoomKey toggles the zoom of the focused pane.
+var zoomKey = ctrl('z')
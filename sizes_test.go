@@ -0,0 +1,101 @@
+package main
+
+import "testing"
+
+func TestPaneSizes(t *testing.T) {
+	var s paneSizes
+	if w, h := s.size(80, 24); w != lw || h != ih {
+		t.Errorf("initial size: got %d, %d", w, h)
+	}
+
+	// A drag can start on either border of a splitter. The splitter
+	// keeps its distance to the pointer.
+	if !s.StartDrag(lw+1, 10, 80, 24) {
+		t.Fatal("no splitter right of the list")
+	}
+	s.Drag(31, 12, 80, 24)
+	if w, _ := s.size(80, 24); w != 30 {
+		t.Errorf("list width: got %d", w)
+	}
+	if !s.EndDrag() || s.Drag(40, 12, 80, 24) {
+		t.Error("drag did not end")
+	}
+
+	// The panes do not shrink below their minimum sizes.
+	s.StartDrag(50, 24-ih, 80, 24)
+	s.Drag(50, 0, 80, 24)
+	if _, h := s.size(80, 24); h != 24-minOutputHeight {
+		t.Errorf("input height: got %d", h)
+	}
+	s.EndDrag()
+	s.StartDrag(30, 5, 80, 24)
+	s.Drag(0, 5, 80, 24)
+	if w, _ := s.size(80, 24); w+1 != minListWidth {
+		t.Errorf("list width: got %d", w)
+	}
+
+	// A smaller terminal shrinks the input pane, but it grows back
+	// with the terminal.
+	if _, h := s.size(80, 10); h != 10-minOutputHeight {
+		t.Errorf("input height in a small terminal: got %d", h)
+	}
+	if _, h := s.size(80, 24); h != 24-minOutputHeight {
+		t.Errorf("input height after resizing: got %d", h)
+	}
+
+	if s.StartDrag(40, 5, 80, 24) {
+		t.Error("drag started inside a pane")
+	}
+}
+
+func TestPaneZoom(t *testing.T) {
+	var s paneSizes
+	s.Resize(-100, 2, 80, 24)
+	if w, h := s.size(80, 24); w+1 != minListWidth || h != ih+2 {
+		t.Errorf("resize: got %d, %d", w, h)
+	}
+
+	s.ToggleZoom()
+	frames := s.frames(80, 24, paneOutput)
+	if len(frames) != 1 || frames[paneOutput] != (rect{0, 0, 79, 23}) {
+		t.Errorf("zoomed: got %v", frames)
+	}
+	if s.StartDrag(minListWidth-1, 5, 80, 24) {
+		t.Error("drag started while zoomed")
+	}
+	s.ToggleZoom()
+	if frames := s.frames(80, 24, paneOutput); len(frames) != 3 {
+		t.Errorf("restored: got %v", frames)
+	}
+}
+
+func TestPaneSizesGrow(t *testing.T) {
+	defer func(h int) { maxInputHeight = h }(maxInputHeight)
+	maxInputHeight = 6
+	s := paneSizes{inputLines: 3}
+	if _, h := s.size(80, 24); h != 5 {
+		t.Errorf("3 lines: got %d", h)
+	}
+	s.inputLines = 10
+	if _, h := s.size(80, 24); h != maxInputHeight {
+		t.Errorf("10 lines: got %d", h)
+	}
+	if _, h := s.size(80, 8); h != 8-minOutputHeight {
+		t.Errorf("10 lines in a small terminal: got %d", h)
+	}
+
+	// Resizing the list pane keeps the input height that the user set,
+	// and so the input pane shrinks back with the input.
+	s.Resize(2, 0, 80, 24)
+	s.inputLines = 1
+	if w, h := s.size(80, 24); w != lw+2 || h != ih {
+		t.Errorf("after resizing the list: got %d, %d", w, h)
+	}
+	// Resizing the input pane goes on from the height it has.
+	s.inputLines = 3
+	s.Resize(0, 1, 80, 24)
+	s.inputLines = 1
+	if _, h := s.size(80, 24); h != 6 {
+		t.Errorf("after resizing the input: got %d", h)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Lines in the input pane that start with a slash are commands for the
+// app, like "/help". Each command registers itself under its name, so
+// that the app can dispatch the lines and Tab can complete the names.
+// The arguments are separated by blanks, like in a shell, and quotes
+// keep blanks within an argument. A line that starts with two slashes
+// is no command but text that starts with one slash.
+
+// A slashCommand describes a command of the input pane. usage lists
+// its arguments, and help says what it does. minArgs and maxArgs limit
+// the number of arguments; a negative maxArgs means no limit. run
+// carries out the command, and complete, if set, returns the words
+// that Tab completes the arguments to.
+type slashCommand struct {
+	usage, help      string
+	minArgs, maxArgs int
+	run              func(a *app, args []string) error
+	complete         func() []string
+}
+
+// slashCommands holds the commands by their names, without the slash.
+var slashCommands = map[string]slashCommand{}
+
+func registerSlashCommand(name string, cmd slashCommand) {
+	slashCommands[name] = cmd
+}
+
+// slashCommandNames returns the names of all registered commands in
+// alphabetical order.
+func slashCommandNames() []string {
+	names := make([]string, 0, len(slashCommands))
+	for name := range slashCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitArgs splits s into arguments at blanks. Within single or double
+// quotes, blanks belong to the argument, and outside of single quotes,
+// a backslash takes the next character literally.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, ch := range s {
+		switch {
+		case escaped:
+			arg.WriteRune(ch)
+			escaped = false
+		case ch == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(ch)
+		case ch == '"' || ch == '\'':
+			quote, inArg = ch, true
+		case wordClass(ch) == 0:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(ch)
+			inArg = true
+		}
+	}
+	switch {
+	case quote != 0:
+		return nil, errors.Errorf("missing closing %c", quote)
+	case escaped:
+		return nil, errors.New("backslash at the end of the line")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// An app is the sample app as the slash commands see it: the backend
+// that draws it, and the items of its list. Writing to an app appends
+// to the output pane.
+type app struct {
+	b     Backend
+	items []string
+}
+
+func (a *app) Write(p []byte) (int, error) {
+	a.b.AppendOutput(string(p))
+	return len(p), nil
+}
+
+// submit handles a line that the user entered. Commands run, and any
+// other line goes to the inputHandler.
+func (a *app) submit(line string) {
+	var err error
+	switch {
+	case strings.HasPrefix(line, "//"):
+		err = inputHandler.HandleInput(line[1:], a)
+	case strings.HasPrefix(line, "/"):
+		err = a.dispatch(line[1:])
+	default:
+		err = inputHandler.HandleInput(line, a)
+	}
+	if err != nil {
+		fmt.Fprintf(a, "Error: %v\n", err)
+	}
+}
+
+// dispatch parses a command line without the leading slash and runs
+// the command.
+func (a *app) dispatch(line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("no command; type /help for a list of commands")
+	}
+	name, args := args[0], args[1:]
+	cmd, ok := slashCommands[name]
+	if !ok {
+		return errors.Errorf("unknown command /%s; type /help for a list of commands", name)
+	}
+	if len(args) < cmd.minArgs || cmd.maxArgs >= 0 && len(args) > cmd.maxArgs {
+		return errors.Errorf("usage: %s", usage(name, cmd))
+	}
+	return errors.Wrap(cmd.run(a, args), "/"+name)
+}
+
+// usage returns the command line of a command, with its arguments.
+func usage(name string, cmd slashCommand) string {
+	return strings.TrimSpace("/" + name + " " + cmd.usage)
+}
+
+// The built-in commands.
+func init() {
+	registerSlashCommand("help", slashCommand{
+		usage: "[command]", help: "list the commands, or describe one",
+		maxArgs: 1, run: runHelp, complete: slashCommandNames,
+	})
+	registerSlashCommand("clear", slashCommand{
+		help: "clear the output pane",
+		run: func(a *app, _ []string) error {
+			a.b.ClearOutput()
+			return nil
+		},
+	})
+	registerSlashCommand("add", slashCommand{
+		usage: "<item>", help: "add an item to the list",
+		minArgs: 1, maxArgs: -1, run: runAdd,
+	})
+	registerSlashCommand("remove", slashCommand{
+		usage: "<item>", help: "remove an item from the list",
+		minArgs: 1, maxArgs: -1, run: runRemove,
+	})
+	registerSlashCommand("theme", slashCommand{
+		usage: "[name]", help: "switch to another color theme, or list the themes",
+		maxArgs: 1, run: runTheme, complete: themeNames,
+	})
+	registerSlashCommand("handler", slashCommand{
+		usage: "[name]", help: "switch to another input handler, or list the handlers",
+		maxArgs: 1, run: runHandler, complete: inputHandlerNames,
+	})
+	registerSlashCommand("quit", slashCommand{
+		help: "quit the app",
+		run: func(a *app, _ []string) error {
+			a.b.Quit()
+			return nil
+		},
+	})
+}
+
+func runHelp(a *app, args []string) error {
+	if len(args) == 1 {
+		name := strings.TrimPrefix(args[0], "/")
+		cmd, ok := slashCommands[name]
+		if !ok {
+			return errors.Errorf("unknown command /%s", name)
+		}
+		fmt.Fprintf(a, "%s: %s\n", usage(name, cmd), cmd.help)
+		return nil
+	}
+	w := 0
+	for _, name := range slashCommandNames() {
+		w = max(w, len(usage(name, slashCommands[name])))
+	}
+	for _, name := range slashCommandNames() {
+		cmd := slashCommands[name]
+		fmt.Fprintf(a, "%-*s  %s\n", w, usage(name, cmd), cmd.help)
+	}
+	return nil
+}
+
+// runAdd and runRemove take the arguments as the words of a single
+// item, so that quotes are only needed for items with multiple blanks
+// in a row.
+func runAdd(a *app, args []string) error {
+	a.items = append(a.items, strings.Join(args, " "))
+	a.b.SetListItems(a.items)
+	return nil
+}
+
+func runRemove(a *app, args []string) error {
+	item := strings.Join(args, " ")
+	for i, s := range a.items {
+		if s == item {
+			a.items = append(a.items[:i:i], a.items[i+1:]...)
+			a.b.SetListItems(a.items)
+			return nil
+		}
+	}
+	return errors.Errorf("no item %q in the list", item)
+}
+
+func runHandler(a *app, args []string) error {
+	if len(args) == 0 {
+		for _, name := range inputHandlerNames() {
+			mark := " "
+			if name == inputHandlerName {
+				mark = "*"
+			}
+			fmt.Fprintf(a, "%s %s\n", mark, name)
+		}
+		return nil
+	}
+	return setInputHandler(args[0])
+}
+
+func runTheme(a *app, args []string) error {
+	if len(args) == 0 {
+		for _, name := range themeNames() {
+			mark := " "
+			if name == themeName {
+				mark = "*"
+			}
+			fmt.Fprintf(a, "%s %s\n", mark, name)
+		}
+		return nil
+	}
+	if _, ok := themes[args[0]]; !ok {
+		return errors.Errorf("no theme %q; the themes are %s", args[0], strings.Join(themeNames(), ", "))
+	}
+	themeName = args[0]
+	return nil
+}
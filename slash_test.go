@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+		err  bool
+	}{
+		{"add Line 6", []string{"add", "Line", "6"}, false},
+		{"  add   x  ", []string{"add", "x"}, false},
+		{`add "two  blanks"`, []string{"add", "two  blanks"}, false},
+		{`add 'a "b"' c\ d`, []string{"add", `a "b"`, "c d"}, false},
+		{`add "" x`, []string{"add", "", "x"}, false},
+		{`add 'it\'s'`, nil, true},
+		{`add "open`, nil, true},
+		{`add x\`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.s)
+		if (err != nil) != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q): got %q, %v", tt.s, got, err)
+		}
+	}
+}
+
+func TestSlashCommands(t *testing.T) {
+	b := newHeadlessBackend(60, 12)
+	a := &app{b: b, items: []string{"Line 1", "Line 2"}}
+	b.SetListItems(a.items)
+	t.Cleanup(func() { themeName = "default" })
+
+	// last returns the last line of the output.
+	last := func() string {
+		lines := b.output.Lines()
+		if len(lines) == 0 {
+			return ""
+		}
+		return lines[len(lines)-1]
+	}
+	tests := []struct {
+		line  string
+		items []string
+		out   string
+	}{
+		{"hello", []string{"Line 1", "Line 2"}, "hello"},
+		{"//not a command", []string{"Line 1", "Line 2"}, "/not a command"},
+		{"/add Line 3", []string{"Line 1", "Line 2", "Line 3"}, ""},
+		{`/add "x  y"`, []string{"Line 1", "Line 2", "Line 3", "x  y"}, ""},
+		{"/remove Line 2", []string{"Line 1", "Line 3", "x  y"}, ""},
+		{"/remove Line 2", []string{"Line 1", "Line 3", "x  y"}, `Error: /remove: no item "Line 2" in the list`},
+		{"/add", []string{"Line 1", "Line 3", "x  y"}, "Error: usage: /add <item>"},
+		{"/nope", []string{"Line 1", "Line 3", "x  y"}, "Error: unknown command /nope; type /help for a list of commands"},
+		{"/help clear", []string{"Line 1", "Line 3", "x  y"}, "/clear: clear the output pane"},
+		{"/theme ocean", []string{"Line 1", "Line 3", "x  y"}, ""},
+	}
+	for _, tt := range tests {
+		before := last()
+		a.submit(tt.line)
+		if !reflect.DeepEqual(a.items, tt.items) || !reflect.DeepEqual(b.list.items, tt.items) {
+			t.Errorf("%s: items: got %q, list %q, want %q", tt.line, a.items, b.list.items, tt.items)
+		}
+		if got := last(); tt.out != "" && got != tt.out || tt.out == "" && got != before {
+			t.Errorf("%s: output: got %q", tt.line, got)
+		}
+	}
+	if themeName != "ocean" || currentTheme().list != colorBlue {
+		t.Errorf("theme: got %q", themeName)
+	}
+
+	a.submit("/help")
+	if out := strings.Join(b.output.Lines(), "\n"); !strings.Contains(out, "/add <item>") || !strings.Contains(out, "/theme [name]") {
+		t.Errorf("help: got\n%s", out)
+	}
+	a.submit("/clear")
+	if n := len(b.output.Lines()); n != 0 {
+		t.Errorf("clear: %d lines left", n)
+	}
+	a.submit("/quit")
+	if !b.quit {
+		t.Error("quit: not quitting")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+)
+
+// tcell works on the same level as termbox: a cell buffer plus an
+// event stream. The tcell backend therefore leaves drawing and key
+// handling to cellPanes and only provides the screen and the event
+// loop.
+type tcellBackend struct {
+	cellPanes
+	s tcell.Screen
+	// buttons are the mouse buttons that were down at the last
+	// mouse event.
+	buttons tcell.ButtonMask
+	// pasteBuf collects the keys of a bracketed paste, which then goes
+	// through the paste guard of cellPanes.
+	pasteBuf pasteBuffer
+}
+
+func init() {
+	registerBackend("tcell", func() Backend { return &tcellBackend{} })
+}
+
+func (b *tcellBackend) Init() error {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return errors.Wrap(err, "Cannot create tcell screen")
+	}
+	err = s.Init()
+	if err != nil {
+		return errors.Wrap(err, "Cannot initialize tcell screen")
+	}
+	s.EnableMouse()
+	s.EnablePaste()
+	b.s = s
+	return nil
+}
+
+func (b *tcellBackend) Close() {
+	b.s.Fini()
+}
+
+// Run redraws the whole screen after each event. tcell only sends the
+// cells that actually changed to the terminal.
+func (b *tcellBackend) Run() error {
+	cv := tcellCanvas{b.s}
+	for {
+		b.draw(cv)
+		b.s.Show()
+		switch ev := b.s.PollEvent().(type) {
+		case nil:
+			// The screen has been finalized.
+			return nil
+		case *tcell.EventResize:
+			b.s.Sync()
+		case *tcell.EventPaste:
+			// The keys in between are the pasted text.
+			if ev.Start() {
+				b.pasteBuf.Start()
+				break
+			}
+			w, h := b.s.Size()
+			if pev, ok := b.pasteBuf.End(); ok && b.handleKey(pev, w, h) {
+				return nil
+			}
+		case *tcell.EventKey:
+			kev := tcellKey(ev)
+			w, h := b.s.Size()
+			if !b.pasteBuf.Add(kev) && b.handleKey(kev, w, h) {
+				return nil
+			}
+		case *tcell.EventMouse:
+			w, h := b.s.Size()
+			b.handleMouse(tcellMouse(ev, b.buttons), w, h)
+			b.buttons = ev.Buttons()
+		}
+	}
+}
+
+// tcellCanvas adds the SetCell method of canvas to a tcell.Screen. All
+// other canvas methods are provided by the screen itself.
+type tcellCanvas struct {
+	tcell.Screen
+}
+
+func (cv tcellCanvas) SetCell(x, y int, ch rune, st style) {
+	ts := tcell.StyleDefault.
+		Foreground(tcellColors[st.fg]).
+		Background(tcellColors[st.bg]).
+		Reverse(st.reverse)
+	cv.SetContent(x, y, ch, nil, ts)
+}
+
+var tcellColors = map[color]tcell.Color{
+	colorDefault: tcell.ColorDefault,
+	colorBlack:   tcell.ColorBlack,
+	colorRed:     tcell.ColorMaroon,
+	colorGreen:   tcell.ColorGreen,
+	colorYellow:  tcell.ColorOlive,
+	colorBlue:    tcell.ColorNavy,
+	colorMagenta: tcell.ColorPurple,
+	colorCyan:    tcell.ColorTeal,
+	colorWhite:   tcell.ColorSilver,
+}
+
+var tcellKeys = map[tcell.Key]key{
+	tcell.KeyEnter:      keyEnter,
+	tcell.KeyTab:        keyTab,
+	tcell.KeyBacktab:    keyBacktab,
+	tcell.KeyBackspace:  keyBackspace,
+	tcell.KeyBackspace2: keyBackspace,
+	tcell.KeyDelete:     keyDelete,
+	tcell.KeyEsc:        keyEsc,
+	tcell.KeyUp:         keyUp,
+	tcell.KeyDown:       keyDown,
+	tcell.KeyLeft:       keyLeft,
+	tcell.KeyRight:      keyRight,
+	tcell.KeyHome:       keyHome,
+	tcell.KeyEnd:        keyEnd,
+	tcell.KeyPgUp:       keyPgUp,
+	tcell.KeyPgDn:       keyPgDn,
+}
+
+// tcellKey translates a tcell key event into a keyEvent.
+func tcellKey(ev *tcell.EventKey) keyEvent {
+	var mod modifier
+	if ev.Modifiers()&tcell.ModCtrl != 0 {
+		mod |= modCtrl
+	}
+	if ev.Modifiers()&tcell.ModAlt != 0 {
+		mod |= modAlt
+	}
+	if ev.Modifiers()&tcell.ModShift != 0 {
+		mod |= modShift
+	}
+	if k, ok := tcellKeys[ev.Key()]; ok {
+		return keyEvent{key: k, mod: mod}
+	}
+	if ev.Key() >= tcell.KeyCtrlA && ev.Key() <= tcell.KeyCtrlZ {
+		return keyEvent{key: keyRune, ch: rune('a' + ev.Key() - tcell.KeyCtrlA), mod: mod | modCtrl}
+	}
+	// Ctrl-/ and Ctrl-_ send the same code.
+	if ev.Key() == tcell.KeyCtrlUnderscore {
+		return keyEvent{key: keyRune, ch: '/', mod: mod | modCtrl}
+	}
+	// With Alt held down, tcell reports Enter by its control code,
+	// as Ctrl-M.
+	if ev.Key() == tcell.KeyRune && ev.Rune() == 'm' && mod == modCtrl|modAlt {
+		return keyEvent{key: keyEnter, mod: modAlt}
+	}
+	if ev.Key() == tcell.KeyRune {
+		return keyEvent{key: keyRune, ch: ev.Rune(), mod: mod}
+	}
+	return keyEvent{key: keyOther, mod: mod}
+}
+
+// tcellMouse translates a tcell mouse event into a mouseEvent. tcell
+// reports which buttons are down rather than presses and releases, so
+// tcellMouse compares the buttons with those of the previous event.
+func tcellMouse(ev *tcell.EventMouse, prev tcell.ButtonMask) mouseEvent {
+	x, y := ev.Position()
+	mev := mouseEvent{x: x, y: y, button: mouseOther}
+	switch btn := ev.Buttons(); {
+	case btn&tcell.WheelUp != 0:
+		mev.button = mouseWheelUp
+	case btn&tcell.WheelDown != 0:
+		mev.button = mouseWheelDown
+	case btn&tcell.Button1 != 0:
+		mev.button = mouseLeft
+		mev.motion = prev&tcell.Button1 != 0
+	case prev&tcell.Button1 != 0:
+		mev.button = mouseRelease
+	}
+	return mev
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	c "github.com/jroimartin/gocui"
+	"github.com/nsf/termbox-go"
+	"github.com/pkg/errors"
+)
+
+// termbox is what termui and gocui are built upon. Without their help,
+// every frame, title, and character has to be put into termbox's cell
+// buffer one by one - which is exactly what cellPanes does. The
+// termbox backend adds the canvas and the event loop.
+type termboxBackend struct {
+	cellPanes
+	seq seqDecoder
+}
+
+func init() {
+	registerBackend("termbox", func() Backend { return &termboxBackend{} })
+}
+
+func (b *termboxBackend) Init() error {
+	err := termbox.Init()
+	if err != nil {
+		return errors.Wrap(err, "Cannot initialize termbox")
+	}
+	// Report Esc-prefixed keys as Alt key combinations, and enable the
+	// mouse.
+	termbox.SetInputMode(termbox.InputAlt | termbox.InputMouse)
+	setBracketedPaste(true)
+	return nil
+}
+
+func (b *termboxBackend) Close() {
+	setBracketedPaste(false)
+	termbox.Close()
+}
+
+// Run redraws the whole cell buffer after each event. termbox compares
+// the buffer against the previous one and only sends the differences
+// to the terminal. After a resize, the Clear call in draw adjusts the
+// buffer to the new terminal size.
+func (b *termboxBackend) Run() error {
+	cv := termboxCanvas{}
+	for {
+		b.draw(cv)
+		err := termbox.Flush()
+		if err != nil {
+			return errors.Wrap(err, "Cannot flush the cell buffer")
+		}
+		ev := termbox.PollEvent()
+		switch ev.Type {
+		case termbox.EventError:
+			return errors.Wrap(ev.Err, "Cannot read events")
+		case termbox.EventKey:
+			w, h := termbox.Size()
+			for _, kev := range b.seq.Decode(termboxKey(ev.Key, ev.Ch, ev.Mod)) {
+				if b.handleKey(kev, w, h) {
+					return nil
+				}
+			}
+		case termbox.EventMouse:
+			w, h := termbox.Size()
+			b.handleMouse(termboxMouse(ev), w, h)
+		}
+	}
+}
+
+// termboxCanvas maps the canvas methods to termbox's global cell
+// buffer.
+type termboxCanvas struct{}
+
+func (termboxCanvas) Size() (int, int) {
+	return termbox.Size()
+}
+
+func (termboxCanvas) Clear() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+}
+
+func (termboxCanvas) SetCell(x, y int, ch rune, st style) {
+	fg := termboxColors[st.fg]
+	if st.reverse {
+		fg |= termbox.AttrReverse
+	}
+	termbox.SetCell(x, y, ch, fg, termboxColors[st.bg])
+}
+
+func (termboxCanvas) ShowCursor(x, y int) {
+	termbox.SetCursor(x, y)
+}
+
+func (termboxCanvas) HideCursor() {
+	termbox.HideCursor()
+}
+
+var termboxColors = map[color]termbox.Attribute{
+	colorDefault: termbox.ColorDefault,
+	colorBlack:   termbox.ColorBlack,
+	colorRed:     termbox.ColorRed,
+	colorGreen:   termbox.ColorGreen,
+	colorYellow:  termbox.ColorYellow,
+	colorBlue:    termbox.ColorBlue,
+	colorMagenta: termbox.ColorMagenta,
+	colorCyan:    termbox.ColorCyan,
+	colorWhite:   termbox.ColorWhite,
+}
+
+var termboxKeys = map[termbox.Key]key{
+	termbox.KeyEnter:      keyEnter,
+	termbox.KeyTab:        keyTab,
+	termbox.KeyBackspace:  keyBackspace,
+	termbox.KeyBackspace2: keyBackspace,
+	termbox.KeyDelete:     keyDelete,
+	termbox.KeyEsc:        keyEsc,
+	termbox.KeyArrowUp:    keyUp,
+	termbox.KeyArrowDown:  keyDown,
+	termbox.KeyArrowLeft:  keyLeft,
+	termbox.KeyArrowRight: keyRight,
+	termbox.KeyHome:       keyHome,
+	termbox.KeyEnd:        keyEnd,
+	termbox.KeyPgup:       keyPgUp,
+	termbox.KeyPgdn:       keyPgDn,
+}
+
+// termboxKey translates a termbox key into a keyEvent. termui and
+// gocui hand out termbox keys as well, so their backends use this, too.
+func termboxKey(k termbox.Key, ch rune, m termbox.Modifier) keyEvent {
+	var mod modifier
+	if m&termbox.ModAlt != 0 {
+		mod |= modAlt
+	}
+	switch {
+	case ch != 0:
+		return keyEvent{key: keyRune, ch: ch, mod: mod}
+	case k == termbox.KeySpace:
+		return keyEvent{key: keyRune, ch: ' ', mod: mod}
+	}
+	if tk, ok := termboxKeys[k]; ok {
+		return keyEvent{key: tk, mod: mod}
+	}
+	if k >= termbox.KeyCtrlA && k <= termbox.KeyCtrlZ {
+		return keyEvent{key: keyRune, ch: rune('a' + k - termbox.KeyCtrlA), mod: mod | modCtrl}
+	}
+	if k == termbox.KeyCtrlSlash {
+		return keyEvent{key: keyRune, ch: '/', mod: mod | modCtrl}
+	}
+	return keyEvent{key: keyOther, mod: mod}
+}
+
+var termboxButtons = map[termbox.Key]mouseButton{
+	termbox.MouseLeft:      mouseLeft,
+	termbox.MouseRelease:   mouseRelease,
+	termbox.MouseWheelUp:   mouseWheelUp,
+	termbox.MouseWheelDown: mouseWheelDown,
+}
+
+// termboxMouse translates a termbox mouse event into a mouseEvent.
+func termboxMouse(ev termbox.Event) mouseEvent {
+	btn, ok := termboxButtons[ev.Key]
+	if !ok {
+		btn = mouseOther
+	}
+	return mouseEvent{
+		x:      ev.MouseX,
+		y:      ev.MouseY,
+		button: btn,
+		motion: ev.Mod&termbox.ModMotion != 0,
+	}
+}
+
+// gocuiKey translates the key of a gocui keybinding or Editor call.
+// gocui's keys and modifiers are termbox's in disguise.
+func gocuiKey(k c.Key, ch rune, m c.Modifier) keyEvent {
+	return termboxKey(termbox.Key(k), ch, termbox.Modifier(m))
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"strings"
+
+	t "github.com/gizak/termui"
+	"github.com/nsf/termbox-go"
+)
+
+// termui's List widget only draws items. termuiList and termuiInput
+// are custom widgets: plain blocks whose Buffer method draws the
+// content of a listModel or a lineEditor, including a selection or a
+// cursor.
+
+// termuiList is a List with a selected item that the user can move
+// with the keyboard.
+type termuiList struct {
+	*t.Block
+	listModel
+	ItemFgColor t.Attribute
+	ItemBgColor t.Attribute
+	// focused decides how the selected item is highlighted.
+	focused bool
+}
+
+func newTermuiList() *termuiList {
+	return &termuiList{
+		Block:       t.NewBlock(),
+		ItemFgColor: t.ThemeAttr("list.item.fg"),
+		ItemBgColor: t.ThemeAttr("list.item.bg"),
+	}
+}
+
+// Buffer implements termui's Bufferer interface. The border label
+// shows the number of items and the filter query.
+func (l *termuiList) Buffer() t.Buffer {
+	r := l.InnerBounds()
+	items, sel := l.Visible(r.Dy())
+	l.BorderLabel = l.Title()
+	buf := l.Block.Buffer()
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return buf
+	}
+	for i, item := range items {
+		fg, bg := l.ItemFgColor, l.ItemBgColor
+		spans := l.Spans(i)
+		if i == sel {
+			fg, bg = termuiStyle(selectedStyle(l.focused), fg, bg)
+			spans = selectedSpans(spans, l.focused)
+		}
+		row := []rune(item)
+		// The highlight of the selected item spans the whole row.
+		for x := 0; x < r.Dx() && (x < len(row) || i == sel); x++ {
+			ch := ' '
+			if x < len(row) {
+				ch = row[x]
+			}
+			cfg, cbg := fg, bg
+			for _, sp := range spans {
+				if x >= sp.start && x < sp.end {
+					cfg, cbg = termuiStyle(sp.st, fg, bg)
+				}
+			}
+			buf.Set(r.Min.X+x, r.Min.Y+i, t.Cell{Ch: ch, Fg: cfg, Bg: cbg})
+		}
+	}
+	return buf
+}
+
+// termuiReverse is reverse video. termui hands its attributes to
+// termbox unchanged, but its own attribute constants stem from an older
+// termbox, and in the current one, t.AttrReverse means hidden text.
+const termuiReverse = t.Attribute(termbox.AttrReverse)
+
+var termuiColors = map[color]t.Attribute{
+	colorBlack:   t.ColorBlack,
+	colorRed:     t.ColorRed,
+	colorGreen:   t.ColorGreen,
+	colorYellow:  t.ColorYellow,
+	colorBlue:    t.ColorBlue,
+	colorMagenta: t.ColorMagenta,
+	colorCyan:    t.ColorCyan,
+	colorWhite:   t.ColorWhite,
+}
+
+// termuiStyle translates st into termui attributes. Default colors in
+// st leave fg and bg as they are.
+func termuiStyle(st style, fg, bg t.Attribute) (t.Attribute, t.Attribute) {
+	if c, ok := termuiColors[st.fg]; ok {
+		fg = c
+	}
+	if c, ok := termuiColors[st.bg]; ok {
+		bg = c
+	}
+	if st.reverse {
+		fg |= termuiReverse
+	}
+	return fg, bg
+}
+
+// termuiOutput is a Par that draws the visible lines of an outputModel
+// instead of its Text, so that it can scroll back. Like termuiInput, it
+// also keeps the Par's markup parser away from user input.
+type termuiOutput struct {
+	*t.Par
+	outputModel
+}
+
+func newTermuiOutput() *termuiOutput {
+	return &termuiOutput{Par: t.NewPar("")}
+}
+
+// Buffer implements termui's Bufferer interface. The border label
+// shows whether the output follows new lines.
+func (o *termuiOutput) Buffer() t.Buffer {
+	o.Align()
+	r := o.InnerBounds()
+	lines := o.Visible(r.Dy())
+	o.BorderLabel = o.Title()
+	buf := o.Block.Buffer()
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return buf
+	}
+	for i, line := range lines {
+		spans := o.Spans(i)
+		for j, ch := range []rune(line) {
+			x := r.Min.X + j
+			if x >= r.Max.X {
+				break
+			}
+			fg, bg := o.TextFgColor, o.TextBgColor
+			for _, sp := range spans {
+				if j >= sp.start && j < sp.end {
+					fg, bg = termuiStyle(sp.st, fg, bg)
+				}
+			}
+			buf.Set(x, r.Min.Y+i, t.Cell{Ch: ch, Fg: fg, Bg: bg})
+		}
+	}
+	return buf
+}
+
+// termuiInput is the edit box that termui lacks: a Par block that
+// draws the content of a lineEditor instead of its Text, plus a cursor
+// in reverse video. termui has no cursor of its own, and the Par's
+// markup parser would misinterpret user input like "[x](fg-red)".
+type termuiInput struct {
+	*t.Par
+	lineEditor
+	history  inputHistory
+	complete completion
+	paste    pasteGuard
+	// The cursor only shows while the input has the focus.
+	focused bool
+}
+
+func newTermuiInput() *termuiInput {
+	return &termuiInput{Par: t.NewPar("")}
+}
+
+// Buffer implements termui's Bufferer interface. The border label
+// shows the text of a history search and vi's normal mode.
+func (in *termuiInput) Buffer() t.Buffer {
+	in.BorderLabel = inputTitle(&in.lineEditor, &in.history, &in.paste)
+	buf := in.Block.Buffer()
+	r := in.InnerBounds()
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return buf
+	}
+	lines, cx, cy := in.Visible(r.Dx(), r.Dy())
+	for y, line := range lines {
+		for x, ch := range line {
+			buf.Set(r.Min.X+x, r.Min.Y+y, t.Cell{Ch: ch, Fg: in.TextFgColor, Bg: in.TextBgColor})
+		}
+	}
+	if !in.focused {
+		return buf
+	}
+	cursor := ' '
+	if cx < len(lines[cy]) {
+		cursor = lines[cy][cx]
+	}
+	buf.Set(r.Min.X+cx, r.Min.Y+cy, t.Cell{Ch: cursor, Fg: in.TextFgColor | termuiReverse, Bg: in.TextBgColor})
+	return buf
+}
+
+// termuiPopup shows the completion candidates of an input block in a
+// block of its own, above the input block. termui draws whatever comes
+// last on top, so render passes it after the panes.
+type termuiPopup struct {
+	in *termuiInput
+}
+
+// Buffer implements termui's Bufferer interface. The buffer is empty
+// while there is no popup.
+func (p termuiPopup) Buffer() t.Buffer {
+	in := p.in
+	ir := rect{in.X, in.Y, in.X + in.Width - 1, in.Y + in.Height - 1}
+	r, cands, sel, ok := in.complete.Popup(ir, &in.lineEditor)
+	if !ok {
+		return t.NewBuffer()
+	}
+	block := t.NewBlock()
+	block.X, block.Y = r.x0, r.y0
+	block.Width, block.Height = r.x1-r.x0+1, r.y1-r.y0+1
+	block.BorderFg, _ = termuiStyle(borderStyle(true), t.ColorDefault, t.ColorDefault)
+	block.Align()
+	buf := block.Buffer()
+	inner := block.InnerBounds()
+	for i, s := range cands {
+		fg, bg := in.TextFgColor, in.TextBgColor
+		if i == sel {
+			fg, bg = termuiStyle(selectedStyle(true), fg, bg)
+		}
+		row := []rune(s)
+		for x := 0; x < inner.Dx(); x++ {
+			ch := ' '
+			if x < len(row) {
+				ch = row[x]
+			}
+			buf.Set(inner.Min.X+x, inner.Min.Y+i, t.Cell{Ch: ch, Fg: fg, Bg: bg})
+		}
+	}
+	return buf
+}
+
+var termuiKeys = map[string]key{
+	"<enter>":     keyEnter,
+	"<tab>":       keyTab,
+	"<backspace>": keyBackspace,
+	"<delete>":    keyDelete,
+	"<escape>":    keyEsc,
+	"<up>":        keyUp,
+	"<down>":      keyDown,
+	"<left>":      keyLeft,
+	"<right>":     keyRight,
+	"<home>":      keyHome,
+	"<end>":       keyEnd,
+	"<previous>":  keyPgUp,
+	"<next>":      keyPgDn,
+	// termui names 0x7F, which most terminals send for Backspace,
+	// after its other meaning, Ctrl-8.
+	"C-8": keyBackspace,
+}
+
+// termuiKey translates the key part of a termui keyboard event path,
+// like "C-a", "M-<up>", or "x", into a keyEvent.
+func termuiKey(s string) keyEvent {
+	var mod modifier
+	if strings.HasPrefix(s, "C-") && s != "C-8" {
+		mod |= modCtrl
+		s = s[2:]
+	}
+	if strings.HasPrefix(s, "M-") && len(s) > 2 {
+		mod |= modAlt
+		s = s[2:]
+	}
+	if k, ok := termuiKeys[s]; ok {
+		return keyEvent{key: k, mod: mod}
+	}
+	if s == "<space>" {
+		return keyEvent{key: keyRune, ch: ' ', mod: mod}
+	}
+	if r := []rune(s); len(r) == 1 {
+		return keyEvent{key: keyRune, ch: r[0], mod: mod}
+	}
+	return keyEvent{key: keyOther, mod: mod}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "sort"
+
+// A theme holds the colors of the sample app. list, output, and input
+// are the colors of the panes, which the backends use for the titles
+// or, like gocui, for the text. text is the color of the content, and
+// focus the color of the focused pane's border. The /theme command
+// switches between the themes; tview keeps its own colors.
+//
+// The default theme has the colors that the backends set up for
+// themselves. gocui colors the text, not the titles, and so it keeps
+// its own colors with the default theme, see gocuiBackend.applyTheme.
+type theme struct {
+	list, output, input color
+	text, focus         color
+}
+
+// themes holds the themes by their names.
+var themes = map[string]theme{
+	"default": {list: colorGreen, output: colorCyan, input: colorYellow, text: colorWhite, focus: colorGreen},
+	"mono":    {text: colorDefault, focus: colorWhite},
+	"ocean":   {list: colorBlue, output: colorCyan, input: colorMagenta, text: colorWhite, focus: colorCyan},
+}
+
+// themeName is the name of the theme that the backends draw with.
+var themeName = "default"
+
+// currentTheme returns the theme that the backends draw with.
+func currentTheme() theme {
+	return themes[themeName]
+}
+
+// themeNames returns the names of all themes in alphabetical order.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
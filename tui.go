@@ -227,6 +227,34 @@ As always, there is some code included to try out at your end. This time, the co
 * A general-purpose output pane in the remaining area
 
 If the library provides a text entry widget, text entered there shall appear in the output pane.
+
+Tab and Shift-Tab move the focus through the list box, the output pane, and the input box, and the border of the focused pane lights up. In the list box, the arrow keys, PgUp/PgDn, and Home/End move the selection, and Enter writes the selected item to the output pane.
+
+Typing in the list box filters the items. The filter is fuzzy: an item matches if it contains the typed characters in this order, not necessarily next to each other. The best matches, like those at the start of words, come first, and the matching characters are highlighted. The title shows how many items match. Backspace takes back a character, and Esc or Ctrl-G clears the filter.
+
+In the output pane, the same keys scroll back through the output. While the output pane shows the most recent lines, it follows new output. Scrolling back pauses this, and the title shows how many lines there are below; scrolling down to the end, or hitting End, resumes it.
+
+The input box edits like a shell: besides the arrow keys, Home, and End, it knows the readline keys of the emacs mode. Ctrl-A and Ctrl-E go to the start and the end of the line, Alt-B and Alt-F move by words, Ctrl-K, Ctrl-U, and Ctrl-W delete to the end, to the start, and the previous word into a kill ring, Ctrl-Y yanks the last deletion back, and Alt-Y right after cycles through the older ones. Ctrl-_ undoes a change, and Alt-/ redoes it. If you prefer vi, the `-vi` option turns on vi mode, with its insert and normal modes.
+
+For text that spans several lines, like a longer message or a code snippet, Alt-Enter inserts a newline, and so does Shift-Enter in terminals that tell it apart from Enter. The input box then grows with the lines, up to ten rows, and the output pane makes room. Enter still submits the input, and so does Ctrl-Enter - or Ctrl-J, which is what many terminals send for Ctrl-Enter. Up and Down move between the lines. (`tview` keeps its single-line input field.)
+
+Pasting text into the input box does not submit it line by line. The app turns on the terminal's bracketed paste mode, in which the terminal marks the start and the end of pasted text, so a paste goes into the input box as a whole, newlines and all, and the input box grows to fit it. A paste always goes to the input box, whichever pane has the focus, and it ends a history search or a completion. A long paste can be a surprise, though, so the app can ask first: the title of the input box then asks whether to paste so many lines, and y or Enter pastes them, while n, Esc, or Ctrl-G drop them. Until then, the app ignores all other keys. (`tview`'s input field keeps a single line of a paste.)
+
+The input box remembers what you entered. Up and Down bring back earlier lines, from the first or the last line of a multi-line input, and Ctrl-R searches them, like in a shell: while you type, the input box shows the most recent line that contains the text, and Ctrl-R again goes to older matches. Esc or Ctrl-G cancels the search, and any other key keeps the line. The history survives a restart, as the lines go into a file in the user's config directory.
+
+Tab completes the word left of the cursor in the input box, like in a shell: a word starting with a slash at the start of the line completes to one of the app's slash commands, a word that looks like a path to a file name, and any other word to a list item or a file in the current directory. If there is more than one candidate, Tab completes as far as they agree, and a popup above the input box shows them. Hitting Tab again cycles through them, Shift-Tab cycles back, Enter keeps the current candidate, and Esc or Ctrl-G go back to what you typed. With nothing to complete, Tab moves the focus as usual.
+
+Lines that start with a slash are commands for the app instead of text for the output pane: `/add` and `/remove` change the list, `/clear` empties the output pane, `/theme` switches the colors, `/quit` quits, and `/help` lists all commands. Arguments are separated by blanks, and quotes keep blanks within an argument, like in a shell. Mistakes, like an unknown command or a missing argument, end up as an error message in the output pane. To enter text that starts with a slash, type two slashes. New commands are a matter of calling `registerSlashCommand` (in `slash.go`) with a function that receives the arguments.
+
+Any other line goes to an `InputHandler` (in `handler.go`), which receives the line and a writer for the output pane. The sample app echoes the lines, but there are two more handlers built in: `calc` evaluates arithmetic like `2 * (3 + 4)`, and `json` pretty-prints JSON. `/handler` switches between them. To embed the layout into a tool of your own, register a handler of your own from `main`.
+
+The output pane can also be searched, like in `less`: `/` searches forward, `?` backward, and the matches are highlighted while typing. Like in Emacs, Alt-R switches between plain text and regular expressions, Alt-C turns on case-sensitive matching, and Enter ends the pattern. Then n and N jump to the next and the previous match. Esc or Ctrl-G ends the search.
+
+To narrow down a long output, `&` filters it like `grep`: the output pane only shows the lines that match the pattern, updating while typing. The same option keys work here, and Ctrl-V inverts the filter. The filter only hides lines, so Esc, or an empty pattern, brings all of them back.
+
+The mouse works, too: a click focuses a pane or selects a list item, the wheel scrolls the output pane, and dragging the border right of the list box or above the input box resizes the panes. (With `termui`, only the dragging works, see below.)
+
+Without a mouse, Ctrl plus an arrow key moves these borders. Ctrl-Z zooms the focused pane to the size of the terminal, and hitting Ctrl-Z again restores the layout.
 */
 
 // Imports and globals
@@ -238,20 +266,24 @@ package main
 // libraries at a time.
 import (
 	"fmt"
+	"image"
 	"log"
 	"os"
+	"strings"
 
 	// Both TUI packages are abbreviated to avoid making the code
 	// overly verbose.
 	t "github.com/gizak/termui"
 	c "github.com/jroimartin/gocui"
+	"github.com/nsf/termbox-go"
 	"github.com/pkg/errors"
 )
 
 const (
-	// List box width.
+	// Initial list box width. The user can drag the border to change
+	// it, and so can the input box height.
 	lw = 20
-	// Input box height.
+	// Initial input box height.
 	ih = 3
 )
 
@@ -265,6 +297,8 @@ var listItems = []string{
 }
 
 /*
+Both libraries have to build the very same app, so the parts that make up the app - three panes, a list to fill, an output to write to, and an input to read from - are described by the `Backend` interface in `backend.go`. Each library implements this interface, and `runBackend` drives the app through it.
+
 ## termui
 
 Let's start with `termui`.
@@ -273,79 +307,394 @@ Let's start with `termui`.
 
 Right now, there is no automatic height adjustment available, but an [issue](https://github.com/gizak/termui/issues/134) has been raised to request this feature.
 
-The test code works around this restriction by manually re-calculating the heights of the list block and the output block before re-aligning and re-rendering the UI.
+The test code works around this restriction by manually re-calculating the heights of the list block and the output block before re-aligning and re-rendering the UI. The panes are resizable, too: the user can drag the border between the list and the output with the mouse, and the border above the input. The height of the input is up to the test code anyway, but the border of the list snaps to the grid, which divides the terminal width into twelfths.
 
 */
 
-//
-func runTermui() {
-	// Initialize termui.
+// The termui backend keeps the three blocks that make up the UI.
+type termuiBackend struct {
+	lb       *termuiList
+	ob       *termuiOutput
+	ib       *termuiInput
+	onSubmit func(string)
+	// focus tracks the block that receives the keys, keys holds the
+	// key bindings of each block, and seq reassembles keys like
+	// Shift-Tab that termui does not know.
+	focus focusRing
+	keys  map[pane]map[keyEvent]func()
+	seq   seqDecoder
+	// sizes holds the pane sizes that the user can change by dragging
+	// the borders, and last is the position of the previous mouse
+	// event. listWidth is where the grid put the border of the list
+	// the last time, or 0 before the first layout.
+	sizes     paneSizes
+	last      image.Point
+	listWidth int
+}
+
+func init() {
+	registerBackend("termui", func() Backend { return &termuiBackend{} })
+}
+
+// Initialize termui.
+func (b *termuiBackend) Init() error {
 	err := t.Init()
 	if err != nil {
-		log.Fatalln("Cannot initialize termui")
-	}
-	// `termui` needs some cleanup when terminating.
-	defer t.Close()
-
-	// Get the height of the terminal.
-	th := t.TermHeight()
-
-	// The list block
-	lb := t.NewList()
-	lb.Height = th
-	lb.BorderLabel = "List"
-	lb.BorderLabelFg = t.ColorGreen
-	lb.BorderFg = t.ColorGreen
-	lb.ItemFgColor = t.ColorWhite
-	lb.Items = listItems
-
-	// The input block. termui has no edit box yet, but at the time of
-	// this writing, there is an open [pull request](https://github.com/gizak/termui/pull/129) for adding
-	// a text input widget.
-	ib := t.NewPar("")
-	ib.Height = ih
-	ib.BorderLabel = "Input"
-	ib.BorderLabelFg = t.ColorYellow
-	ib.BorderFg = t.ColorYellow
-	ib.TextFgColor = t.ColorWhite
-
-	// The Output block.
-	ob := t.NewPar("\nPress Ctrl-C to quit")
-	ob.Height = th - ih
-	ob.BorderLabel = "Output"
-	ob.BorderLabelFg = t.ColorCyan
-	ob.BorderFg = t.ColorCyan
-	ob.TextFgColor = t.ColorWhite
-
-	// Now we need to create the layout. The blocks have gotten a size
-	// but no position. A grid layout puts everything into place.
-	// t.Body is a pre-defined grid. We add one row that contains
-	// two columns.
-	//
-	// The grid uses a 12-column system, so we have to give a "span"
-	// parameter to each column that specifies how many grid column
-	// each column occupies.
-	t.Body.AddRows(
-		t.NewRow(
-			t.NewCol(3, 0, lb),
-			t.NewCol(9, 0, ob, ib)))
+		return errors.Wrap(err, "Cannot initialize termui")
+	}
+	// termui does not turn on the mouse, but termbox, which termui is
+	// built upon, can do that. In InputAlt mode, termbox reports
+	// Esc-prefixed keys as Alt key combinations, like for the other
+	// termbox-based backends. In InputEsc mode, the Esc of each escape
+	// sequence that termbox does not know would arrive as a key of
+	// its own.
+	termbox.SetInputMode(termbox.InputAlt | termbox.InputMouse)
+	// Neither of them turns on bracketed paste mode, see `paste.go`.
+	setBracketedPaste(true)
+	return nil
+}
+
+// `termui` needs some cleanup when terminating.
+func (b *termuiBackend) Close() {
+	setBracketedPaste(false)
+	t.Close()
+}
+
+// The list block. termui's List cannot select an item, so `termuiList`
+// (in `termuiwidgets.go`) is a block that draws the list with the
+// selected item highlighted.
+func (b *termuiBackend) BuildList() error {
+	b.lb = newTermuiList()
+	b.lb.BorderLabel = "List"
+	b.lb.BorderLabelFg = t.ColorGreen
+	b.lb.BorderFg = t.ColorGreen
+	b.lb.ItemFgColor = t.ColorWhite
+	return nil
+}
+
+// The Output block. A Par block cannot scroll, so `termuiOutput` draws
+// the most recent lines, or older ones when the user scrolls back. In
+// the latter case, it adds the number of lines below to the label.
+func (b *termuiBackend) BuildOutput() error {
+	b.ob = newTermuiOutput()
+	b.ob.BorderLabel = "Output"
+	b.ob.BorderLabelFg = t.ColorCyan
+	b.ob.BorderFg = t.ColorCyan
+	b.ob.TextFgColor = t.ColorWhite
+	return nil
+}
+
+// The input block. termui has no edit box yet, but at the time of
+// this writing, there is an open [pull request](https://github.com/gizak/termui/pull/129) for adding
+// a text input widget. Until then, `termuiInput` (in `termuiwidgets.go`)
+// fills the gap: a Par block that draws an editable line and a cursor.
+// It also keeps the history of the submitted lines.
+func (b *termuiBackend) BuildInput() error {
+	b.ib = newTermuiInput()
+	b.ib.BorderLabel = "Input"
+	b.ib.BorderLabelFg = t.ColorYellow
+	b.ib.BorderFg = t.ColorYellow
+	b.ib.TextFgColor = t.ColorWhite
+	b.ib.vi = viMode
+	// A broken history file only costs the history.
+	if err := b.ib.history.Load(historyPath); err != nil {
+		log.Println(err)
+	}
+	return nil
+}
+
+// The output block keeps the text line by line.
+func (b *termuiBackend) AppendOutput(s string) {
+	b.ob.Append(s)
+}
+
+func (b *termuiBackend) ClearOutput() {
+	b.ob.Clear()
+}
+
+// The list block takes its items as a plain string slice.
+func (b *termuiBackend) SetListItems(items []string) {
+	b.lb.SetItems(items)
+}
+
+// The keyboard handler in Run calls f when the user hits Enter.
+func (b *termuiBackend) OnSubmit(f func(string)) {
+	b.onSubmit = f
+}
+
+// Quit stops the event loop, just like Ctrl-C does.
+func (b *termuiBackend) Quit() {
+	t.StopLoop()
+}
+
+// termui has no notion of focus, so the backend tracks which block
+// gets the keys, and tells the blocks so that they can draw the
+// selection and the cursor accordingly. applyTheme highlights the
+// border of the focused block.
+func (b *termuiBackend) setFocus(p pane) {
+	b.focus.Set(p)
+	b.lb.focused = p == paneList
+	b.ib.focused = p == paneInput
+}
+
+// blocks returns the block of each pane.
+func (b *termuiBackend) blocks() map[pane]*t.Block {
+	return map[pane]*t.Block{
+		paneList:   b.lb.Block,
+		paneOutput: &b.ob.Block,
+		paneInput:  &b.ib.Block,
+	}
+}
+
+// termui's key handlers are global, too. Like gocui's keybindings for
+// a view, a binding made with bind only fires while block p has the
+// focus.
+func (b *termuiBackend) bind(p pane, ev keyEvent, f func()) {
+	if b.keys == nil {
+		b.keys = map[pane]map[keyEvent]func(){}
+	}
+	if b.keys[p] == nil {
+		b.keys[p] = map[keyEvent]func(){}
+	}
+	b.keys[p][ev] = f
+}
+
+// Enter writes the selected list item to the output. In the input
+// block, Enter may insert a newline instead of submitting the input,
+// so handleKey asks the line editor which keys submit.
+func (b *termuiBackend) bindKeys() {
+	b.bind(paneList, keyEvent{key: keyEnter}, func() {
+		if item, ok := b.lb.Selected(); ok {
+			b.AppendOutput(item + "\n")
+		}
+	})
+}
+
+// submit hands the input over to the function that OnSubmit set.
+func (b *termuiBackend) submit() {
+	line := b.ib.String()
+	b.ib.Reset()
+	err := b.ib.history.Add(line)
+	if err != nil {
+		log.Println(err)
+	}
+	if b.onSubmit != nil {
+		b.onSubmit(line)
+	}
+}
+
+// handleKey passes a key to the focused block: first to its key
+// bindings, then to the list or the line editor. Pastes and completion
+// in the input block come before everything else, as completion takes
+// over Tab and Enter while its popup shows. handleKey reports whether
+// the key had any effect.
+func (b *termuiBackend) handleKey(ev keyEvent) bool {
+	p := b.focus.Pane()
+	switch {
+	case b.ib.paste.HandleKey(ev, &b.ib.lineEditor):
+		b.setFocus(paneInput)
+		b.ib.history.searching, b.ib.complete.active = false, false
+		return true
+	case p == paneInput && !b.ib.history.searching && b.ib.complete.HandleKey(ev, &b.ib.lineEditor, b.lb.items):
+		return true
+	case ev.key == keyTab:
+		b.setFocus(b.focus.Next())
+		return true
+	case ev.key == keyBacktab:
+		b.setFocus(b.focus.Prev())
+		return true
+	case ev == zoomKey:
+		b.sizes.ToggleZoom()
+		return true
+	}
+	if d, ok := resizeKeys[ev]; ok {
+		// The border of the list moves by a grid column.
+		tw := t.TermWidth()
+		b.sizes.Resize(d.dw*max(1, tw/12), d.dh, tw, t.TermHeight())
+		return true
+	}
+	if f, ok := b.keys[p][ev]; ok {
+		f()
+		return true
+	}
+	switch p {
+	case paneList:
+		return b.lb.HandleKey(ev)
+	case paneOutput:
+		return b.ob.HandleKey(ev)
+	case paneInput:
+		switch {
+		case b.ib.history.HandleKey(ev, &b.ib.lineEditor):
+		case b.ib.submitKey(ev):
+			b.submit()
+		default:
+			return b.ib.HandleKey(ev)
+		}
+		return true
+	}
+	return false
+}
+
+// Now we need to create the layout. The blocks have no position yet.
+// A grid layout puts everything into place. We need one row that
+// contains two columns.
+//
+// The grid uses a 12-column system, so we have to give a "span"
+// parameter to each column that specifies how many grid column
+// each column occupies.
+func (b *termuiBackend) row() *t.Row {
+	return t.NewRow(
+		t.NewCol(3, 0, b.lb),
+		t.NewCol(9, 0, b.ob, b.ib))
+}
+
+// The grid only takes care of the widths. The heights of the list box
+// and the output box must be set manually before aligning the grid,
+// and so must the height of the input box, which paneSizes (in
+// `sizes.go`) calculates. It grows with the lines of the input.
+//
+// If the user has moved the border of the list, align changes the
+// spans of the grid's columns to put the border as close to it as the
+// grid allows, and tells paneSizes where it ended up. While a block is
+// zoomed, its position and size are set directly, as they are just
+// public fields.
+func (b *termuiBackend) align(grid *t.Grid, tw, th int) {
+	b.sizes.inputLines = b.ib.Lines()
+	w, h := b.sizes.size(tw, th)
+	if b.listWidth != 0 && w != b.listWidth {
+		span := gridSpan(w+1, tw)
+		cols := grid.Rows[0].Cols
+		cols[0].Span, cols[1].Span = span, 12-span
+	}
+	b.lb.Height = th
+	b.ob.Height = th - h
+	b.ib.Height = h
+	grid.Width = tw
+	grid.Align()
+	b.listWidth = b.lb.Width - 1
+	b.sizes.setListWidth(b.listWidth)
+	if b.sizes.zoom {
+		block := b.blocks()[b.focus.Pane()]
+		block.X, block.Y = 0, 0
+		block.Width, block.Height = tw, th
+	}
+}
+
+// gridSpan returns the number of grid columns that come closest to w
+// terminal columns, for a terminal that is tw columns wide. It leaves
+// the list and the output their minimum widths, if the terminal is
+// wide enough, and both of them at least one grid column.
+func gridSpan(w, tw int) int {
+	span := (24*w + tw) / (2 * tw)
+	span = min(span, 12-(12*minOutputWidth+tw-1)/tw)
+	span = max(span, (12*minListWidth+tw-1)/tw)
+	return max(1, min(span, 11))
+}
+
+// The colors of the blocks are public fields, too. The /theme command
+// can change them any time, so render sets them before each drawing.
+func (b *termuiBackend) applyTheme() {
+	th := currentTheme()
+	attr := func(c color) t.Attribute {
+		fg, _ := termuiStyle(style{fg: c}, t.ColorDefault, t.ColorDefault)
+		return fg
+	}
+	b.lb.BorderLabelFg, b.lb.ItemFgColor = attr(th.list), attr(th.text)
+	b.ob.BorderLabelFg, b.ob.TextFgColor = attr(th.output), attr(th.text)
+	b.ib.BorderLabelFg, b.ib.TextFgColor = attr(th.input), attr(th.text)
+	for p, block := range b.blocks() {
+		block.BorderFg = attr(borderStyle(p == b.focus.Pane()).fg)
+	}
+}
+
+// shown reports whether the block of pane p is visible. While a block
+// is zoomed, the others are neither drawn nor clickable.
+func (b *termuiBackend) shown(p pane) bool {
+	return !b.sizes.zoom || p == b.focus.Pane()
+}
+
+// render positions the visible blocks and draws them, and then the
+// completion popup on top. Together the blocks cover the whole
+// terminal, so there is no need to clear it first.
+func (b *termuiBackend) render() {
+	b.align(t.Body, t.TermWidth(), t.TermHeight())
+	b.applyTheme()
+	var bs []t.Bufferer
+	for p, w := range map[pane]t.Bufferer{paneList: b.lb, paneOutput: b.ob, paneInput: b.ib} {
+		if b.shown(p) {
+			bs = append(bs, w)
+		}
+	}
+	if b.shown(paneInput) {
+		bs = append(bs, termuiPopup{b.ib})
+	}
+	t.Render(bs...)
+}
+
+// termui's mouse events only carry the position of the pointer, but
+// not the button. A turn of the wheel looks just like a click, so
+// clicks neither focus a block nor select an item: turning the wheel
+// over the list would do that, too. What remains is dragging the
+// borders, which must be guessed: a drag starts with an event on the
+// border and moves the border along with the pointer. Releasing the
+// button reports the position of the last move again, which ends the
+// drag.
+func (b *termuiBackend) mouse(x, y int) bool {
+	pt := image.Pt(x, y)
+	last := b.last
+	b.last = pt
+	tw, th := t.TermWidth(), t.TermHeight()
+	if b.sizes.drag != noSplitter && pt == last {
+		b.sizes.EndDrag()
+		return false
+	}
+	if b.sizes.Drag(x, y, tw, th) {
+		return true
+	}
+	b.sizes.StartDrag(x, y, tw, th)
+	return false
+}
+
+// Assemble the blocks and run the event loop.
+func (b *termuiBackend) Run() error {
+	b.bindKeys()
+	b.setFocus(paneInput)
+
+	// t.Body is a pre-defined grid that receives our row.
+	t.Body.AddRows(b.row())
 
 	// Render the grid.
-	t.Body.Align()
-	t.Render(t.Body)
+	b.render()
 
 	// When the window resizes, the grid must adopt to the new size.
 	// We use a hander func for this.
 	t.Handle("/sys/wnd/resize", func(t.Event) {
-		// Update the heights of list box and output box.
-		lb.Height = t.TermHeight()
-		ob.Height = t.TermHeight() - ih
-		t.Body.Width = t.TermWidth()
-		t.Body.Align()
-		t.Render(t.Body)
+		b.render()
 	})
 
-	//
+	// Text entry, list navigation, and focus changes. A handler for
+	// "/sys/kbd" receives every key that has no handler of its own.
+	// termui passes the key as a string like "C-a" or "<left>", which
+	// termuiKey translates for handleKey.
+	t.Handle("/sys/kbd", func(e t.Event) {
+		changed := false
+		for _, ev := range b.seq.Decode(termuiKey(e.Data.(t.EvtKbd).KeyStr)) {
+			if b.handleKey(ev) {
+				changed = true
+			}
+		}
+		if changed {
+			b.render()
+		}
+	})
+
+	// Mouse events go to "/sys/mouse".
+	t.Handle("/sys/mouse", func(e t.Event) {
+		m := e.Data.(t.EvtMouse)
+		if b.mouse(m.X, m.Y) {
+			b.render()
+		}
+	})
 
 	// We need a way out. Ctrl-C shall stop the event loop.
 	t.Handle("/sys/kbd/C-c", func(t.Event) {
@@ -354,168 +703,809 @@ func runTermui() {
 
 	// start the event loop.
 	t.Loop()
+	return nil
 }
 
 /*
-That wasn't too difficult, was it? Text entry is missing here, as `termui`
+That wasn't too difficult, was it? Text entry needed some extra work, as `termui`
 has no input controls yet. Still, implementing an input box is possible with
-the available API methods; see the `_example` subdirectory in `termui`'s repository. It is just too much code for this blog article, so I leave this as an exercise to the reader.
+the available API methods: a custom block draws the text and the cursor, and a catch-all keyboard handler does the editing. (See also the `_example` subdirectory in `termui`'s repository.)
+
+Mouse support is where `termui` falls short. Its mouse events only tell where the pointer is, but not which button was pressed or released, or whether the wheel turned. The wheel cannot scroll the output pane, as wheel up and wheel down look exactly the same. Worse, a turn of the wheel looks exactly like a click, so clicks cannot focus a pane or select a list item either: turning the wheel over the list would do the same. Dragging the borders works, though.
 
 ## gocui
 
 Now let's see how `gocui` solves the same task.
 */
 
-// Set up the widgets and run the event loop.
-func runGocui() {
-	// Create a new GUI.
+// The gocui backend needs the GUI object and the state of the list;
+// the views can be retrieved by name.
+type gocuiBackend struct {
+	g        *c.Gui
+	list     listModel
+	output   outputModel
+	focus    focusRing
+	input    lineEditor
+	seq      seqDecoder
+	sizes    paneSizes
+	history  inputHistory
+	complete completion
+	paste    pasteGuard
+	onSubmit func(string)
+	// builtin holds the colors that Init and the Build methods set
+	// up, by view name, and "" for the GUI's SelFgColor.
+	builtin map[string]c.Attribute
+}
+
+func init() {
+	registerBackend("gocui", func() Backend { return &gocuiBackend{} })
+}
+
+// Create a new GUI.
+func (b *gocuiBackend) Init() error {
 	g, err := c.NewGui(c.OutputNormal)
 	if err != nil {
-		log.Println("Failed to create a GUI:", err)
-		return
+		return errors.Wrap(err, "Failed to create a GUI")
 	}
-	defer g.Close()
+	b.g = g
+
+	// gocui does not turn on bracketed paste mode, see `paste.go`.
+	setBracketedPaste(true)
 
 	// Activate the cursor for the current view.
 	g.Cursor = true
 
+	// Let gocui report mouse events. Like keys, they trigger
+	// keybindings.
+	g.Mouse = true
+
+	// Highlight the frame of the current view. Themes other than the
+	// default one change the color, see `applyTheme`.
+	g.Highlight = true
+	g.SelFgColor = c.ColorGreen
+
 	// The GUI object wants to know how to manage the layout.
 	// Unlike `termui`, `gocui` does not use
 	// a grid layout. Instead, it relies on a custom layout handler function
 	// to manage the layout.
 
-	// Here we set the layout manager to a function named `layout`
+	// Here we set the layout manager to a method named `layout`
 	// that is defined further down.
-	g.SetManagerFunc(layout)
+	g.SetManagerFunc(b.layout)
+
+	// The borders between the views can be dragged with the mouse.
+	// gocui only reports mouse events inside of a view, though, so
+	// two frameless views lie beneath the borders. They are created
+	// before all other views, so that the frames of those are drawn
+	// on top. The layout function moves them into place.
+	for _, name := range []string{"listborder", "inputborder"} {
+		v, err := g.SetView(name, 0, 0, 1, 1)
+		if err != nil && err != c.ErrUnknownView {
+			return errors.Wrap(err, "Failed to create border view")
+		}
+		v.Frame = false
+	}
 
 	// Bind the `quit` handler function (also defined further down) to Ctrl-C,
 	// so that we can leave the application at any time.
 	err = g.SetKeybinding("", c.KeyCtrlC, c.ModNone, quit)
 	if err != nil {
-		log.Println("Could not set key binding:", err)
-		return
+		return errors.Wrap(err, "Could not set key binding")
 	}
 
-	// Now let's define the views.
+	// Tab moves the focus to the next view, unless it completes a
+	// word in the input view.
+	err = g.SetKeybinding("", c.KeyTab, c.ModNone, func(g *c.Gui, v *c.View) error {
+		ev := keyEvent{key: keyTab}
+		if b.pasteKey(v, c.KeyTab) || b.pasteInput(ev) || b.completeKey(ev) {
+			return nil
+		}
+		return b.setFocus(b.focus.Next())
+	})
+	if err != nil {
+		return errors.Wrap(err, "Could not set key binding")
+	}
 
-	// The terminal's width and height are needed for layout calculations.
+	// Shift-Tab moves it back. gocui does not know this key, though:
+	// it arrives as Alt-[ followed by a Z. A keybinding catches the
+	// Alt-[, and the views' editors (see `edit` below) catch the rest.
+	err = g.SetKeybinding("", '[', c.ModAlt, func(g *c.Gui, v *c.View) error {
+		b.seq.Decode(altBracket)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Could not set key binding")
+	}
+	// Ctrl-Z zooms the current view. The keys that resize the views
+	// are Ctrl plus an arrow, which gocui does not know either, so
+	// they are left to the editors, too.
+	err = g.SetKeybinding("", c.KeyCtrlZ, c.ModNone, func(*c.Gui, *c.View) error {
+		if !b.pasteInput(zoomKey) {
+			b.sizes.ToggleZoom()
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Could not set key binding")
+	}
+	err = b.bindListKeys()
+	if err != nil {
+		return err
+	}
+	err = b.bindOutputKeys()
+	if err != nil {
+		return err
+	}
+	return b.bindMouse()
+}
+
+// A mouse keybinding fires for the view under the pointer. Before
+// calling the handler, gocui moves that view's cursor to the pointer.
+// A click focuses the view, the wheel scrolls the output view, and
+// dragging a border view resizes the other views.
+func (b *gocuiBackend) bindMouse() error {
+	err := b.g.SetKeybinding("", c.MouseLeft, c.ModNone, b.click)
+	if err != nil {
+		return errors.Wrap(err, "Could not set mouse binding")
+	}
+	// While a button is held down, moving the mouse repeats the
+	// button's event with the motion modifier. gocui does not name
+	// that modifier, but passes it on from termbox.
+	err = b.g.SetKeybinding("", c.MouseLeft, c.Modifier(termbox.ModMotion), b.drag)
+	if err != nil {
+		return errors.Wrap(err, "Could not set mouse binding")
+	}
+	err = b.g.SetKeybinding("", c.MouseRelease, c.ModNone, func(*c.Gui, *c.View) error {
+		b.sizes.EndDrag()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Could not set mouse binding")
+	}
+	for k, n := range map[c.Key]int{c.MouseWheelUp: wheelLines, c.MouseWheelDown: -wheelLines} {
+		err = b.g.SetKeybinding("output", k, c.ModNone, func(*c.Gui, *c.View) error {
+			b.output.Scroll(n)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "Could not set mouse binding")
+		}
+	}
+	return nil
+}
+
+// click focuses the view that was clicked. In the list, it also selects
+// the item under the pointer; in the input, it moves the cursor there.
+// A click on a border starts dragging it.
+func (b *gocuiBackend) click(g *c.Gui, v *c.View) error {
 	tw, th := g.Size()
+	if x, y := pointer(g, v); b.sizes.StartDrag(x, y, tw, th) {
+		return nil
+	}
+	p, ok := paneByName(v.Name())
+	if !ok {
+		return nil
+	}
+	err := b.setFocus(p)
+	if err != nil {
+		return err
+	}
+	cx, cy := v.Cursor()
+	switch p {
+	case paneList:
+		b.list.SelectVisible(cy)
+	case paneInput:
+		b.input.Click(cx, cy)
+	}
+	return nil
+}
+
+// drag moves the border that is being dragged, if any, to the pointer.
+// The layout function then resizes the views accordingly.
+func (b *gocuiBackend) drag(g *c.Gui, v *c.View) error {
+	tw, th := g.Size()
+	x, y := pointer(g, v)
+	b.sizes.Drag(x, y, tw, th)
+	return nil
+}
+
+// pointer returns the screen position of the mouse pointer, which
+// gocui has turned into the cursor position of view v.
+func pointer(g *c.Gui, v *c.View) (x, y int) {
+	x0, y0, _, _, _ := g.ViewPosition(v.Name())
+	cx, cy := v.Cursor()
+	return x0 + 1 + cx, y0 + 1 + cy
+}
+
+// setFocus makes the view of pane p the current view. Only the input
+// view shows the cursor.
+func (b *gocuiBackend) setFocus(p pane) error {
+	b.focus.Set(p)
+	_, err := b.g.SetCurrentView(paneNames[p])
+	if err != nil {
+		return errors.Wrap(err, "Cannot set focus")
+	}
+	b.g.Cursor = p == paneInput
+	return nil
+}
+
+// gocui passes the keys that have no keybinding to the Editor of the
+// current view, if the view is editable. All three views are, so that
+// the Editor can finish the sequences that seqDecoder knows. The list
+// view takes the other keys for filtering, the output view for
+// searching, and the input view for editing. gocui's DefaultEditor
+// only knows the most basic editing keys, so the input view's Editor
+// uses a lineEditor instead, which knows those of a shell. The layout
+// function then draws the line into the view.
+func (b *gocuiBackend) edit(v *c.View, key c.Key, ch rune, mod c.Modifier) {
+	evs := b.seq.Decode(gocuiKey(key, ch, mod))
+	for _, ev := range evs {
+		d, resize := resizeKeys[ev]
+		switch {
+		case b.pasteInput(ev):
+		case b.completeKey(ev):
+		case ev.key == keyBacktab:
+			err := b.setFocus(b.focus.Prev())
+			if err != nil {
+				log.Println(err)
+			}
+		case resize:
+			tw, th := b.g.Size()
+			b.sizes.Resize(d.dw, d.dh, tw, th)
+		case v.Name() == "list":
+			b.list.HandleKey(ev)
+		case v.Name() == "output":
+			b.output.HandleKey(ev)
+		case v.Name() != "input":
+		case b.history.HandleKey(ev, &b.input):
+		case b.input.submitKey(ev):
+			b.submit()
+		default:
+			b.input.HandleKey(ev)
+		}
+	}
+}
+
+// Pasted text arrives as keys, and Enter and Tab have keybindings, which
+// fire before the Editor gets these keys. pasteKey passes them on to the
+// Editor while a paste is in progress, so that they end up in the
+// pasted text, and reports whether one is.
+func (b *gocuiBackend) pasteKey(v *c.View, key c.Key) bool {
+	if !b.seq.Pasting() {
+		return false
+	}
+	b.edit(v, key, 0, c.ModNone)
+	return true
+}
+
+// pasteInput inserts a paste into the input view, or takes the answer
+// to the question whether to insert a long paste, and reports whether
+// ev was either. Until the answer comes, it takes every key, so the
+// keybindings ask it first, too.
+func (b *gocuiBackend) pasteInput(ev keyEvent) bool {
+	if !b.paste.HandleKey(ev, &b.input) {
+		return false
+	}
+	b.history.searching, b.complete.active = false, false
+	err := b.setFocus(paneInput)
+	if err != nil {
+		log.Println(err)
+	}
+	return true
+}
+
+// completeKey passes a key to the completion in the input view and
+// reports whether the completion took it. While the popup shows, it
+// takes Tab, Shift-Tab, Enter, Esc, and Ctrl-G.
+func (b *gocuiBackend) completeKey(ev keyEvent) bool {
+	return b.focus.Pane() == paneInput && !b.history.searching && b.complete.HandleKey(ev, &b.input, b.list.items)
+}
 
-	// First, create the list view.
-	lv, err := g.SetView("list", 0, 0, lw, th-1)
+// Keybindings can be restricted to a view. The navigation keys and
+// Enter only act on the list while the list view is the current view.
+func (b *gocuiBackend) bindListKeys() error {
+	for _, k := range []c.Key{c.KeyArrowUp, c.KeyArrowDown, c.KeyPgup, c.KeyPgdn, c.KeyHome, c.KeyEnd} {
+		ev := gocuiKey(k, 0, c.ModNone)
+		err := b.g.SetKeybinding("list", k, c.ModNone, func(*c.Gui, *c.View) error {
+			if !b.pasteInput(ev) {
+				b.list.HandleKey(ev)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "Could not set key binding")
+		}
+	}
+	err := b.g.SetKeybinding("list", c.KeyEnter, c.ModNone, func(_ *c.Gui, v *c.View) error {
+		if b.pasteKey(v, c.KeyEnter) || b.pasteInput(keyEvent{key: keyEnter}) {
+			return nil
+		}
+		if item, ok := b.list.Selected(); ok {
+			b.AppendOutput(item + "\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Could not set key binding")
+	}
+	return nil
+}
+
+// The output view scrolls with the same navigation keys.
+func (b *gocuiBackend) bindOutputKeys() error {
+	for _, k := range []c.Key{c.KeyArrowUp, c.KeyArrowDown, c.KeyPgup, c.KeyPgdn, c.KeyHome, c.KeyEnd} {
+		ev := gocuiKey(k, 0, c.ModNone)
+		err := b.g.SetKeybinding("output", k, c.ModNone, func(*c.Gui, *c.View) error {
+			if !b.pasteInput(ev) {
+				b.output.HandleKey(ev)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "Could not set key binding")
+		}
+	}
+	return nil
+}
+
+// Like `termui`, `gocui` needs some cleanup when terminating.
+func (b *gocuiBackend) Close() {
+	setBracketedPaste(false)
+	b.g.Close()
+}
+
+// Now let's define the views.
+
+// First, create the list view.
+func (b *gocuiBackend) BuildList() error {
+	// The terminal's width and height are needed for layout calculations.
+	// paneRects (in `backend.go`) does the math for all backends but
+	// termui, which has its grid.
+	r, _, _ := b.sizes.paneRects(b.g.Size())
+
+	lv, err := b.g.SetView("list", r.x0, r.y0, r.x1, r.y1)
 	// ErrUnknownView is not a real error condition.
 	// It just says that the view did not exist before and needs initialization.
 	if err != nil && err != c.ErrUnknownView {
-		log.Println("Failed to create main view:", err)
-		return
+		return errors.Wrap(err, "Failed to create main view")
 	}
 	lv.Title = "List"
 	lv.FgColor = c.ColorCyan
+	lv.Editable = true
+	lv.Editor = c.EditorFunc(b.edit)
+	// Highlight the line with the view's cursor. The layout function
+	// moves the cursor to the selected item. gocui draws the whole
+	// line in the selection colors, so the selected item does not show
+	// which characters match the filter.
+	lv.Highlight = true
+	return nil
+}
 
-	// Then the output view.
-	ov, err := g.SetView("output", lw+1, 0, tw-1, th-ih-1)
+// Then the output view.
+func (b *gocuiBackend) BuildOutput() error {
+	_, r, _ := b.sizes.paneRects(b.g.Size())
+	ov, err := b.g.SetView("output", r.x0, r.y0, r.x1, r.y1)
 	if err != nil && err != c.ErrUnknownView {
-		log.Println("Failed to create output view:", err)
-		return
+		return errors.Wrap(err, "Failed to create output view")
 	}
 	ov.Title = "Output"
 	ov.FgColor = c.ColorGreen
-	// Let the view scroll if the output exceeds the visible area.
-	ov.Autoscroll = true
-	_, err = fmt.Fprintln(ov, "Press Ctrl-c to quit")
-	if err != nil {
-		log.Println("Failed to print into output view:", err)
-	}
+	ov.Editable = true
+	ov.Editor = c.EditorFunc(b.edit)
+	return nil
+}
 
-	// And finally the input view.
-	iv, err := g.SetView("input", lw+1, th-ih, tw-1, th-1)
+// And finally the input view.
+func (b *gocuiBackend) BuildInput() error {
+	_, _, r := b.sizes.paneRects(b.g.Size())
+	iv, err := b.g.SetView("input", r.x0, r.y0, r.x1, r.y1)
 	if err != nil && err != c.ErrUnknownView {
-		log.Println("Failed to create input view:", err)
-		return
+		return errors.Wrap(err, "Failed to create input view")
 	}
 	iv.Title = "Input"
 	iv.FgColor = c.ColorYellow
 	// The input view shall be editable.
 	iv.Editable = true
-	err = iv.SetCursor(0, 0)
-	if err != nil {
-		log.Println("Failed to set cursor:", err)
-		return
+	iv.Editor = c.EditorFunc(b.edit)
+	b.input.vi = viMode
+	// The submitted lines go into a history. If the history file
+	// cannot be read, the input view works without the old lines.
+	if err := b.history.Load(historyPath); err != nil {
+		log.Println(err)
 	}
+	return nil
+}
 
-	// Make the enter key copy the input to the output.
-	err = g.SetKeybinding("input", c.KeyEnter, c.ModNone, func(g *c.Gui, iv *c.View) error {
-		// We want to read the view's buffer from the beginning.
-		iv.Rewind()
+// A view could simply scroll to its end with Autoscroll, but the
+// output view can scroll back, too. Like the list, the output goes
+// into a model, and the layout function fills the view with the lines
+// that fit.
+func (b *gocuiBackend) AppendOutput(s string) {
+	b.output.Append(s)
+}
 
-		// Get the output view via its name.
-		ov, e := g.View("output")
-		if e != nil {
-			log.Println("Cannot get output view:", e)
-			return e
-		}
-		// Thanks to views being an io.Writer, we can simply Fprint to a view.
-		_, e = fmt.Fprint(ov, iv.Buffer())
-		if e != nil {
-			log.Println("Cannot print to output view:", e)
-		}
-		// Clear the input view
-		iv.Clear()
-		// Put the cursor back to the start.
-		e = iv.SetCursor(0, 0)
-		if e != nil {
-			log.Println("Failed to set cursor:", e)
-		}
-		return e
+func (b *gocuiBackend) ClearOutput() {
+	b.output.Clear()
+}
 
+// The list view only shows the items that fit, so the items go into
+// the list model, and the layout function fills the view.
+func (b *gocuiBackend) SetListItems(items []string) {
+	b.list.SetItems(items)
+}
+
+// Make the enter key hand the input over to f. Other keys that submit,
+// like Ctrl-J, have no keybinding and go to the Editor. So does Enter
+// with Alt, which inserts a newline.
+func (b *gocuiBackend) OnSubmit(f func(string)) {
+	b.onSubmit = f
+	err := b.g.SetKeybinding("input", c.KeyEnter, c.ModNone, func(g *c.Gui, iv *c.View) error {
+		ev := keyEvent{key: keyEnter}
+		switch {
+		case b.pasteKey(iv, c.KeyEnter):
+		// Enter confirms a long paste, or picks a candidate of the
+		// completion popup, if it shows.
+		case b.pasteInput(ev):
+		case b.completeKey(ev):
+		case b.input.submitKey(ev):
+			b.submit()
+		default:
+			b.input.HandleKey(ev)
+		}
+		return nil
 	})
 	if err != nil {
 		log.Println("Cannot bind the enter key:", err)
 	}
+}
 
-	// Fill the list view.
-	for _, s := range listItems {
-		// Again, we can simply Fprint to a view.
-		_, err = fmt.Fprintln(lv, s)
-		if err != nil {
-			log.Println("Error writing to the list view:", err)
-			return
-		}
+// submit hands the input over to the function that OnSubmit set. The
+// input is in the line editor, not in the view.
+func (b *gocuiBackend) submit() {
+	line := b.input.String()
+	b.input.Reset()
+	err := b.history.Add(line)
+	if err != nil {
+		log.Println(err)
 	}
+	b.onSubmit(line)
+}
+
+// A keybinding ends the main loop by returning ErrQuit. Quit cannot
+// return anything to the main loop, but a function that it queues with
+// Update can.
+func (b *gocuiBackend) Quit() {
+	b.g.Update(func(*c.Gui) error {
+		return c.ErrQuit
+	})
+}
 
+// Set the focus and run the main loop.
+func (b *gocuiBackend) Run() error {
 	// Set the focus to the input view.
-	_, err = g.SetCurrentView("input")
+	err := b.setFocus(paneInput)
 	if err != nil {
-		log.Println("Cannot set focus to input view:", err)
+		log.Println(err)
 	}
 
 	// Start the main loop.
-	err = g.MainLoop()
-	log.Println("Main loop has finished:", err)
+	err = b.g.MainLoop()
+	if err != nil && err != c.ErrQuit {
+		return errors.Wrap(err, "Main loop has finished")
+	}
+	return nil
 }
 
 // The layout handler calculates all sizes depending
-// on the current terminal size.
-func layout(g *c.Gui) error {
-	// Get the current terminal size.
+// on the current terminal size. gocui calls it before every redraw, so
+// it is also the place to fill the list view.
+func (b *gocuiBackend) layout(g *c.Gui) error {
+	// The input view grows with the lines of the input.
+	b.sizes.inputLines = b.input.Lines()
+	err := layoutViews(g, b.sizes)
+	if err != nil {
+		return err
+	}
+	err = b.layoutBorders(g)
+	if err != nil {
+		return err
+	}
+	err = b.layoutZoom(g)
+	if err != nil {
+		return err
+	}
+	err = b.applyTheme(g)
+	if err != nil {
+		return err
+	}
+	err = b.drawList(g)
+	if err != nil {
+		return err
+	}
+	err = b.drawInput(g)
+	if err != nil {
+		return err
+	}
+	err = b.drawOutput(g)
+	if err != nil {
+		return err
+	}
+	return b.drawPopup(g)
+}
+
+// gocui draws the text of a view in the view's FgColor, and the frame
+// and the title of the current view in the GUI's SelFgColor. The
+// /theme command can change these colors any time, so the layout
+// function sets them before each redraw. The default theme brings
+// back the colors from Init and the Build methods, which the first
+// call saves. Other themes set gocui's colors, which are termbox's.
+func (b *gocuiBackend) applyTheme(g *c.Gui) error {
+	th := currentTheme()
+	if b.builtin == nil {
+		b.builtin = map[string]c.Attribute{"": g.SelFgColor}
+	}
+	attr := func(name string, col color) c.Attribute {
+		if themeName == "default" {
+			return b.builtin[name]
+		}
+		return c.Attribute(termboxColors[col])
+	}
+	g.SelFgColor = attr("", th.focus)
+	for name, col := range map[string]color{"list": th.list, "output": th.output, "input": th.input} {
+		v, err := g.View(name)
+		if err != nil {
+			return errors.Wrap(err, "Cannot get view")
+		}
+		if _, ok := b.builtin[name]; !ok {
+			b.builtin[name] = v.FgColor
+		}
+		v.FgColor = attr(name, col)
+	}
+	return nil
+}
+
+// drawInput writes the visible part of the input into the input view
+// and puts the view's cursor where the line editor has it.
+func (b *gocuiBackend) drawInput(g *c.Gui) error {
+	iv, err := g.View("input")
+	if err != nil {
+		return errors.Wrap(err, "Cannot get input view")
+	}
+	// The title shows the text of a history search and vi's mode.
+	iv.Title = inputTitle(&b.input, &b.history, &b.paste)
+	w, h := iv.Size()
+	lines, cx, cy := b.input.Visible(w, h)
+	rows := make([]string, len(lines))
+	for i, line := range lines {
+		rows[i] = string(line)
+	}
+	iv.Clear()
+	_, err = fmt.Fprint(iv, strings.Join(rows, "\n"))
+	if err != nil {
+		return errors.Wrap(err, "Error writing to the input view")
+	}
+	if w <= 0 {
+		return nil
+	}
+	err = iv.SetCursor(cx, cy)
+	if err != nil {
+		return errors.Wrap(err, "Failed to set cursor")
+	}
+	return nil
+}
+
+// drawPopup shows the completion candidates in a view of their own,
+// above the input view, and deletes that view when the popup closes.
+// The view's cursor, and thus its highlight, is on the current
+// candidate.
+func (b *gocuiBackend) drawPopup(g *c.Gui) error {
 	tw, th := g.Size()
+	ir, ok := b.sizes.frames(tw, th, b.focus.Pane())[paneInput]
+	var r rect
+	var cands []string
+	sel := -1
+	if ok {
+		r, cands, sel, ok = b.complete.Popup(ir, &b.input)
+	}
+	if !ok {
+		err := g.DeleteView("popup")
+		if err != nil && err != c.ErrUnknownView {
+			return errors.Wrap(err, "Cannot delete popup view")
+		}
+		return nil
+	}
+	pv, err := g.SetView("popup", r.x0, r.y0, r.x1, r.y1)
+	if err != nil && err != c.ErrUnknownView {
+		return errors.Wrap(err, "Cannot update popup view")
+	}
+	pv.FgColor = c.Attribute(termboxColors[currentTheme().text])
+	pv.SelFgColor, pv.SelBgColor = c.ColorBlack, c.ColorCyan
+	pv.Highlight = sel >= 0
+	pv.Clear()
+	for _, s := range cands {
+		_, err = fmt.Fprintln(pv, s)
+		if err != nil {
+			return errors.Wrap(err, "Error writing to the popup view")
+		}
+	}
+	err = pv.SetCursor(0, max(0, sel))
+	if err != nil {
+		return errors.Wrap(err, "Failed to set cursor")
+	}
+	_, err = g.SetViewOnTop("popup")
+	if err != nil {
+		return errors.Wrap(err, "Cannot raise popup view")
+	}
+	return nil
+}
+
+// drawOutput writes the visible part of the output into the output
+// view.
+func (b *gocuiBackend) drawOutput(g *c.Gui) error {
+	ov, err := g.View("output")
+	if err != nil {
+		return errors.Wrap(err, "Cannot get output view")
+	}
+	_, h := ov.Size()
+	lines := b.output.Visible(h)
+	// The title tells whether the view follows new output.
+	ov.Title = b.output.Title()
+	ov.Clear()
+	for i, s := range lines {
+		// Thanks to views being an io.Writer, we can simply Fprint to
+		// a view.
+		_, err = fmt.Fprintln(ov, highlight(s, b.output.Spans(i)))
+		if err != nil {
+			return errors.Wrap(err, "Error writing to the output view")
+		}
+	}
+	return nil
+}
+
+// gocui views understand ANSI escape sequences for colors, so
+// highlighting parts of a line, like search matches, is a matter of
+// wrapping them into such sequences. The reset at the end of a part
+// does not lose the view's colors: gocui draws cells with the default
+// colors in the view's FgColor and BgColor, which the theme sets.
+func highlight(line string, spans []span) string {
+	if len(spans) == 0 {
+		return line
+	}
+	text := []rune(line)
+	var sb strings.Builder
+	pos := 0
+	for _, sp := range spans {
+		sb.WriteString(string(text[pos:sp.start]))
+		sb.WriteString(sgr(sp.st))
+		sb.WriteString(string(text[sp.start:sp.end]))
+		sb.WriteString(sgr(style{}))
+		pos = sp.end
+	}
+	sb.WriteString(string(text[pos:]))
+	return sb.String()
+}
+
+// sgr returns the escape sequence that switches to style st.
+func sgr(st style) string {
+	codes := []string{"0"}
+	if st.fg != colorDefault {
+		codes = append(codes, fmt.Sprint(29+int(st.fg)))
+	}
+	if st.bg != colorDefault {
+		codes = append(codes, fmt.Sprint(39+int(st.bg)))
+	}
+	if st.reverse {
+		codes = append(codes, "7")
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// drawList writes the visible part of the list into the list view and
+// puts the view's cursor, and thus the highlight, on the selected item.
+func (b *gocuiBackend) drawList(g *c.Gui) error {
+	lv, err := g.View("list")
+	if err != nil {
+		return errors.Wrap(err, "Cannot get list view")
+	}
+	w, h := lv.Size()
+	items, sel := b.list.Visible(h)
+	// The title shows the number of items and the filter query.
+	lv.Title = b.list.Title()
+	lv.Clear()
+	for i, s := range items {
+		// Again, we can simply Fprint to a view. The selected item
+		// is padded so that the highlight spans the whole line.
+		if i == sel {
+			s = fmt.Sprintf("%-*s", w, s)
+		}
+		_, err = fmt.Fprintln(lv, highlight(s, b.list.Spans(i)))
+		if err != nil {
+			return errors.Wrap(err, "Error writing to the list view")
+		}
+	}
+	if sel >= 0 {
+		err = lv.SetCursor(0, sel)
+		if err != nil {
+			return errors.Wrap(err, "Failed to set cursor")
+		}
+	}
+	// The selection stands out more while the list has the focus.
+	if g.CurrentView() == lv {
+		lv.SelFgColor, lv.SelBgColor = c.ColorBlack, c.ColorCyan
+	} else {
+		lv.SelFgColor, lv.SelBgColor = c.ColorYellow, c.ColorDefault
+	}
+	return nil
+}
+
+// The layout math only needs these two methods of the GUI, so
+// layoutViews also works with a stand-in for the GUI. The layout tests
+// make use of this to run without a terminal.
+type viewSetter interface {
+	Size() (x, y int)
+	SetView(name string, x0, y0, x1, y1 int) (*c.View, error)
+}
+
+// layoutViews does the actual work for `layout`.
+func layoutViews(g viewSetter, s paneSizes) error {
+	// Get the pane coordinates for the current terminal size.
+	lr, or, ir := s.paneRects(g.Size())
 
 	// Update the views according to the new terminal size.
-	_, err := g.SetView("list", 0, 0, lw, th-1)
+	_, err := g.SetView("list", lr.x0, lr.y0, lr.x1, lr.y1)
 	if err != nil {
 		return errors.Wrap(err, "Cannot update list view")
 	}
-	_, err = g.SetView("output", lw+1, 0, tw-1, th-ih-1)
+	_, err = g.SetView("output", or.x0, or.y0, or.x1, or.y1)
 	if err != nil {
 		return errors.Wrap(err, "Cannot update output view")
 	}
-	_, err = g.SetView("input", lw+1, th-ih, tw-1, th-1)
+	_, err = g.SetView("input", ir.x0, ir.y0, ir.x1, ir.y1)
 	if err != nil {
 		return errors.Wrap(err, "Cannot update input view.")
 	}
 	return nil
 }
 
+// layoutBorders moves the border views beneath the borders between the
+// list and the output view, and between the output and the input view.
+// The interior of a view, where gocui reports mouse events, begins one
+// cell right of and below the top-left corner.
+func (b *gocuiBackend) layoutBorders(g *c.Gui) error {
+	tw, th := g.Size()
+	lr, _, ir := b.sizes.paneRects(tw, th)
+	_, err := g.SetView("listborder", lr.x1-1, -1, lr.x1+2, th)
+	if err != nil {
+		return errors.Wrap(err, "Cannot update list border view")
+	}
+	_, err = g.SetView("inputborder", ir.x0-1, ir.y0-2, ir.x1+1, ir.y0+1)
+	if err != nil {
+		return errors.Wrap(err, "Cannot update input border view")
+	}
+	return nil
+}
+
+// gocui cannot hide a view, but views can overlap. While zoomed, the
+// current view covers the whole terminal and lies on top of the other
+// views.
+func (b *gocuiBackend) layoutZoom(g *c.Gui) error {
+	if !b.sizes.zoom {
+		return nil
+	}
+	tw, th := g.Size()
+	p := b.focus.Pane()
+	r := b.sizes.frames(tw, th, p)[p]
+	_, err := g.SetView(paneNames[p], r.x0, r.y0, r.x1, r.y1)
+	if err != nil {
+		return errors.Wrap(err, "Cannot zoom view")
+	}
+	_, err = g.SetViewOnTop(paneNames[p])
+	if err != nil {
+		return errors.Wrap(err, "Cannot zoom view")
+	}
+	return nil
+}
+
 // `quit` is a handler that gets bound to Ctrl-C.
 // It signals the main loop to exit.
 func quit(g *c.Gui, v *c.View) error {
@@ -523,25 +1513,43 @@ func quit(g *c.Gui, v *c.View) error {
 }
 
 /*
-Our main func just needs to read the name from the TUI lib from the command line
-and execute the respective code.
+Our main func just needs to read the name from the TUI lib from the command line,
+look it up in the backend registry, and run the app with it. A few names are not TUI libs but commands, like `evaluate`. Before that, main registers the input handlers that the user can pick.
 */
 
 //
 func main() {
+	// The built-in input handlers. A tool of your own would register
+	// its own handler here.
+	registerInputHandler("echo", InputHandlerFunc(echoInput))
+	registerInputHandler("calc", InputHandlerFunc(calcInput))
+	registerInputHandler("json", InputHandlerFunc(jsonInput))
+
 	if len(os.Args) <= 1 {
-		log.Println("Usage: go run tui.go [termui|gocui]")
+		log.Printf("Usage: go run . [%s]\n", strings.Join(append(backendNames(), commandNames()...), "|"))
+		return
+	}
+	if run, ok := commands[os.Args[1]]; ok {
+		err := run(os.Args[2:])
+		if err != nil {
+			log.Println(err)
+		}
 		return
 	}
-	if os.Args[1] == "termui" {
-		runTermui()
+	newBackend, ok := backends[os.Args[1]]
+	if !ok {
+		log.Println("No such option:", os.Args[1])
 		return
 	}
-	if os.Args[1] == "gocui" {
-		runGocui()
+	err := parseBackendFlags(os.Args[1], os.Args[2:])
+	if err != nil {
+		log.Println(err)
 		return
 	}
-	log.Println("No such option:", os.Args[0])
+	err = runBackend(newBackend())
+	if err != nil {
+		log.Println(err)
+	}
 }
 
 /*
@@ -565,10 +1573,45 @@ Step 2: `cd` to the source code directory.
 
     cd $GOPATH/src/github.com/appliedgo/tui
 
-Step 3. Run the binary with either "termui" or "gocui" as a parameter.
+Step 3. Run the binary with "termui", "gocui", "termbox", "tcell", or "tview" as a parameter. (The code is spread across a few files now, so run the package rather than `tui.go` alone.)
+
+    go run . termui
+    go run . gocui
+    go run . termbox
+    go run . tcell
+    go run . tview
+
+The `-taborder` option changes the order in which Tab visits the panes, or leaves panes out:
+
+    go run . gocui -taborder input,list
+
+The `-history` option sets the file that keeps the input history. An empty name turns off saving the history:
+
+    go run . termbox -history ""
+
+The `-vi` option switches the input box to vi's key bindings:
+
+    go run . termui -vi
+
+The `-handler` option picks the input handler, like the calculator:
+
+    go run . gocui -handler calc
+
+The `-maxinput` option sets the height up to which the input box grows with multi-line input:
+
+    go run . tcell -maxinput 16
+
+The `-pasteconfirm` option asks before pasting more than the given number of lines:
+
+    go run . gocui -pasteconfirm 20
+
+To compare the libraries side by side after all, let the `evaluate` command run each of them in a virtual terminal through the same scenario. It prints a Markdown table of the results, or JSON with `-json`.
+
+    go run . evaluate
+
+Or watch two of them handle the same input at the same time: `sidebyside` runs two libraries (`termui` and `gocui` by default) in the left and right half of the terminal. F1 switches the focus between the halves, F2 sends every key to both, and F10 quits.
 
-    go run tui.go termui
-    go run tui.go gocui
+    go run . sidebyside termui gocui
 
 
 **Happy coding!**
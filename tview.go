@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tview sits on top of tcell and brings its own widgets, so each pane
+// maps to a single tview primitive: a List, a TextView, and an
+// InputField. A Flex container takes the role of gocui's layout
+// function.
+type tviewBackend struct {
+	app      *tview.Application
+	list     *tview.List
+	out      *tview.TextView
+	in       *tview.InputField
+	onSubmit func(string)
+}
+
+func init() {
+	registerBackend("tview", func() Backend { return &tviewBackend{} })
+}
+
+// The application takes over the terminal only when it starts
+// running.
+func (b *tviewBackend) Init() error {
+	b.app = tview.NewApplication()
+	return nil
+}
+
+// When Run returns, tview has already restored the terminal.
+func (b *tviewBackend) Close() {}
+
+func (b *tviewBackend) BuildList() error {
+	b.list = tview.NewList().ShowSecondaryText(false)
+	b.list.SetBorder(true).SetTitle("List").SetTitleAlign(tview.AlignLeft)
+	return nil
+}
+
+func (b *tviewBackend) BuildOutput() error {
+	b.out = tview.NewTextView().SetScrollable(true)
+	b.out.SetBorder(true).SetTitle("Output").SetTitleAlign(tview.AlignLeft)
+	return nil
+}
+
+// The InputField calls its done func for Enter, Tab, and Escape. Only
+// Enter submits the text.
+func (b *tviewBackend) BuildInput() error {
+	b.in = tview.NewInputField()
+	b.in.SetBorder(true).SetTitle("Input").SetTitleAlign(tview.AlignLeft)
+	b.in.SetDoneFunc(func(k tcell.Key) {
+		if k != tcell.KeyEnter {
+			return
+		}
+		line := b.in.GetText()
+		b.in.SetText("")
+		if b.onSubmit != nil {
+			b.onSubmit(line)
+		}
+	})
+	return nil
+}
+
+// A TextView is an io.Writer, just like a gocui view.
+func (b *tviewBackend) AppendOutput(s string) {
+	fmt.Fprint(b.out, s)
+	b.out.ScrollToEnd()
+}
+
+func (b *tviewBackend) ClearOutput() {
+	b.out.Clear()
+}
+
+func (b *tviewBackend) SetListItems(items []string) {
+	b.list.Clear()
+	for _, item := range items {
+		b.list.AddItem(item, "", 0, nil)
+	}
+}
+
+func (b *tviewBackend) OnSubmit(f func(string)) {
+	b.onSubmit = f
+}
+
+// Stopping the application ends Run, whether it happens within the
+// event loop or not.
+func (b *tviewBackend) Quit() {
+	b.app.Stop()
+}
+
+// Run arranges the panes like paneRects does: the list gets a fixed
+// width of lw+1 columns (both borders included), the input a fixed
+// height of ih rows, and the output takes the remaining space.
+// tview stops the application on Ctrl-C by itself.
+func (b *tviewBackend) Run() error {
+	right := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(b.out, 0, 1, false).
+		AddItem(b.in, ih, 0, true)
+	root := tview.NewFlex().
+		AddItem(b.list, lw+1, 0, false).
+		AddItem(right, 0, 1, true)
+	// tview inserts a paste into the focused input field as a whole.
+	return b.app.SetRoot(root, true).SetFocus(b.in).EnablePaste(true).Run()
+}
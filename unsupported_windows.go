@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import "github.com/pkg/errors"
+
+// The commands that run the backends in a virtual terminal need a
+// pseudo terminal, which Windows does not have. On Windows, they only
+// say so.
+
+func init() {
+	registerCommand("evaluate", unsupported("evaluate"))
+	registerCommand("sidebyside", unsupported("sidebyside"))
+}
+
+// unsupported returns a command that fails because it does not work on
+// Windows.
+func unsupported(name string) func(args []string) error {
+	return func([]string) error {
+		return errors.New(name + " is not supported on Windows")
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+// With the -vi flag, the input pane edits like vi, or like a shell in
+// vi mode: it starts in insert mode, where the emacs keys still work,
+// and Esc switches to normal mode, where letters are commands. In
+// normal mode, the cursor stays on a character, and Enter still
+// submits the line.
+
+// viMode turns on vi mode for the input pane. The -vi flag sets it.
+var viMode bool
+
+// viKey applies a key in vi mode. prev is the kind of the previous
+// command.
+func (e *lineEditor) viKey(ev keyEvent, prev editOp) bool {
+	if !e.normal {
+		switch {
+		case ev.key == keyEsc && ev.mod == 0:
+			e.enterNormal()
+			return true
+		case ev.key == keyRune && ev.mod == modAlt:
+			// termbox's InputAlt mode turns Esc and a following
+			// key into the key with Alt.
+			e.enterNormal()
+			ev.mod = 0
+		default:
+			return e.emacsKey(ev, prev)
+		}
+	}
+	handled := e.viNormalKey(ev)
+	e.fixCursor()
+	return handled
+}
+
+// enterNormal switches to normal mode. Like in vi, the cursor moves
+// back onto the last inserted character.
+func (e *lineEditor) enterNormal() {
+	e.normal, e.pending = true, 0
+	e.Left()
+}
+
+// insert switches to insert mode.
+func (e *lineEditor) insert() {
+	e.normal = false
+}
+
+// fixCursor keeps the cursor on a character in normal mode, unless the
+// line is empty.
+func (e *lineEditor) fixCursor() {
+	if e.normal && (e.cursor >= len(e.text) || e.text[e.cursor] == '\n') && e.cursor > e.lineStart() {
+		e.cursor--
+	}
+}
+
+// viWordStart returns the start of the next word, viWordEnd the index
+// of the last character of the current or next word, and viWordBack
+// the start of the current or previous word. A vi word is a run of
+// word characters or a run of other non-blank characters.
+func (e *lineEditor) viWordStart() int {
+	i, n := e.cursor, len(e.text)
+	if i < n {
+		cl := wordClass(e.text[i])
+		for i < n && cl != 0 && wordClass(e.text[i]) == cl {
+			i++
+		}
+	}
+	for i < n && wordClass(e.text[i]) == 0 {
+		i++
+	}
+	return i
+}
+
+func (e *lineEditor) viWordEnd() int {
+	i, n := e.cursor+1, len(e.text)
+	for i < n && wordClass(e.text[i]) == 0 {
+		i++
+	}
+	if i >= n {
+		return max(0, n-1)
+	}
+	cl := wordClass(e.text[i])
+	for i+1 < n && wordClass(e.text[i+1]) == cl {
+		i++
+	}
+	return i
+}
+
+func (e *lineEditor) viWordBack() int {
+	i := e.cursor
+	for i > 0 && wordClass(e.text[i-1]) == 0 {
+		i--
+	}
+	if i > 0 {
+		cl := wordClass(e.text[i-1])
+		for i > 0 && wordClass(e.text[i-1]) == cl {
+			i--
+		}
+	}
+	return i
+}
+
+// viMotion returns the target of a motion key in normal mode, and
+// whether the target character is part of the range that an operator
+// like d works on.
+func (e *lineEditor) viMotion(ev keyEvent) (to int, inclusive, ok bool) {
+	switch {
+	case ev.key == keyLeft, ev == keyEvent{key: keyRune, ch: 'h'}, ev.key == keyBackspace:
+		return max(0, e.cursor-1), false, true
+	case ev.key == keyRight, ev == keyEvent{key: keyRune, ch: 'l'}, ev == keyEvent{key: keyRune, ch: ' '}:
+		return min(len(e.text), e.cursor+1), false, true
+	case ev.key == keyHome, ev == keyEvent{key: keyRune, ch: '0'}:
+		return e.lineStart(), false, true
+	case ev == keyEvent{key: keyRune, ch: '^'}:
+		i := e.lineStart()
+		for i < len(e.text) && e.text[i] != '\n' && wordClass(e.text[i]) == 0 {
+			i++
+		}
+		return i, false, true
+	case ev.key == keyEnd, ev == keyEvent{key: keyRune, ch: '$'}:
+		return e.lineEnd(), false, true
+	case ev == keyEvent{key: keyRune, ch: 'w'}:
+		return e.viWordStart(), false, true
+	case ev == keyEvent{key: keyRune, ch: 'e'}:
+		return e.viWordEnd(), true, true
+	case ev == keyEvent{key: keyRune, ch: 'b'}:
+		return e.viWordBack(), false, true
+	}
+	return 0, false, false
+}
+
+// viOperate applies the operator op, which is d, c, or y, to the range
+// from the cursor to the target of the motion key ev. Doubling the
+// operator, like dd, applies it to the whole line.
+func (e *lineEditor) viOperate(op rune, ev keyEvent) {
+	from, to := 0, len(e.text)
+	if ev != (keyEvent{key: keyRune, ch: op}) {
+		target, inclusive, ok := e.viMotion(ev)
+		if !ok {
+			return
+		}
+		// Like in vim, cw changes to the end of the word.
+		if op == 'c' && ev.ch == 'w' && e.cursor < len(e.text) && wordClass(e.text[e.cursor]) != 0 {
+			target, inclusive = e.viWordEnd(), true
+		}
+		from, to = min(e.cursor, target), max(e.cursor, target)
+		if inclusive {
+			to = min(to+1, len(e.text))
+		}
+	}
+	switch op {
+	case 'y':
+		if from < to {
+			e.pushKill(string(e.text[from:to]))
+		}
+		e.cursor = from
+	case 'd':
+		e.kill(from, to, opOther)
+	case 'c':
+		e.kill(from, to, opOther)
+		e.insert()
+	}
+}
+
+// viNormalKey applies a key in normal mode and reports whether the key
+// has a meaning there. Unknown letters do nothing, but they count as
+// handled, so that they do not go into the line.
+func (e *lineEditor) viNormalKey(ev keyEvent) bool {
+	if p := e.pending; p != 0 {
+		e.pending = 0
+		switch {
+		case p == 'r' && ev.key == keyRune && ev.mod == 0:
+			if e.cursor < len(e.text) {
+				e.checkpoint()
+				e.text[e.cursor] = ev.ch
+			}
+		case p != 'r':
+			e.viOperate(p, ev)
+		}
+		return true
+	}
+	if to, _, ok := e.viMotion(ev); ok {
+		e.cursor = to
+		return true
+	}
+	if ev.key == keyEsc {
+		return true
+	}
+	if ev.key != keyRune {
+		return false
+	}
+	switch ev {
+	case ctrl('r'):
+		e.Redo()
+		return true
+	case keyEvent{key: keyRune, ch: ev.ch}:
+	default:
+		return false
+	}
+	switch ev.ch {
+	case 'd', 'c', 'y', 'r':
+		e.pending = ev.ch
+	case 'i':
+		e.insert()
+	case 'a':
+		e.Right()
+		e.insert()
+	case 'I':
+		e.Home()
+		e.insert()
+	case 'A':
+		e.End()
+		e.insert()
+	case 'x':
+		e.kill(e.cursor, min(e.cursor+1, len(e.text)), opOther)
+	case 'X':
+		e.kill(max(0, e.cursor-1), e.cursor, opOther)
+	case 'D':
+		e.kill(e.cursor, len(e.text), opOther)
+	case 'C':
+		e.kill(e.cursor, len(e.text), opOther)
+		e.insert()
+	case 's':
+		e.kill(e.cursor, min(e.cursor+1, len(e.text)), opOther)
+		e.insert()
+	case 'S':
+		e.kill(0, len(e.text), opOther)
+		e.insert()
+	case 'p', 'P':
+		// Like vi, put the kill after the cursor or before it, and
+		// leave the cursor on its last character.
+		if len(e.kills) == 0 {
+			break
+		}
+		if ev.ch == 'p' && len(e.text) > 0 {
+			e.Right()
+		}
+		e.Yank()
+		e.Left()
+	case 'u':
+		e.Undo()
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/hinshun/vt10x"
+	"github.com/pkg/errors"
+)
+
+// A vterm runs a command in a pseudo terminal and feeds everything the
+// command writes into a terminal emulator. The emulator's screen is a
+// virtual screen that can be inspected at any time, no matter which
+// TUI library the command uses.
+type vterm struct {
+	cmd  *exec.Cmd
+	pty  *os.File
+	term vt10x.Terminal
+	// changed receives a value whenever the command has written to
+	// the terminal. It does not queue up more than one notification.
+	changed chan struct{}
+	// done gets closed when the command has terminated.
+	done chan struct{}
+}
+
+// Glyph mode bits for text attributes. vt10x does not export them.
+const (
+	vtReverse = 1 << iota
+	vtUnderline
+	vtBold
+)
+
+// selfCommand returns a command that runs this program with the given
+// arguments. Tests replace it, as their executable is the test binary.
+var selfCommand = func(args ...string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot find the executable")
+	}
+	return exec.Command(exe, args...), nil
+}
+
+// startVterm starts cmd in a pseudo terminal of size w x h.
+func startVterm(cmd *exec.Cmd, w, h int) (*vterm, error) {
+	cmd.Env = append(cmd.Environ(), "TERM=xterm-256color")
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(w), Rows: uint16(h)})
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot start "+cmd.Path)
+	}
+	v := &vterm{
+		cmd:     cmd,
+		pty:     f,
+		term:    vt10x.New(vt10x.WithWriter(f), vt10x.WithSize(w, h)),
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go v.read()
+	go func() {
+		// Only the end of the command matters, not its exit status.
+		cmd.Wait()
+		close(v.done)
+	}()
+	return v, nil
+}
+
+// read copies the command's output into the emulator until the pseudo
+// terminal gets closed.
+func (v *vterm) read() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := v.pty.Read(buf)
+		if n > 0 {
+			v.term.Write(buf[:n])
+			select {
+			case v.changed <- struct{}{}:
+			default:
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send writes s to the command's input, as if it was typed.
+func (v *vterm) Send(s string) error {
+	_, err := v.pty.Write([]byte(s))
+	return err
+}
+
+// Resize changes the size of both the emulator and the pseudo
+// terminal. The latter sends SIGWINCH to the command.
+func (v *vterm) Resize(w, h int) error {
+	v.term.Resize(w, h)
+	return pty.Setsize(v.pty, &pty.Winsize{Cols: uint16(w), Rows: uint16(h)})
+}
+
+// Size returns the size of the emulated screen.
+func (v *vterm) Size() (w, h int) {
+	return v.term.Size()
+}
+
+// Cell returns the glyph at x, y.
+func (v *vterm) Cell(x, y int) vt10x.Glyph {
+	v.term.Lock()
+	defer v.term.Unlock()
+	return v.term.Cell(x, y)
+}
+
+// Cursor returns the cursor position and whether the command shows
+// the cursor.
+func (v *vterm) Cursor() (x, y int, visible bool) {
+	v.term.Lock()
+	defer v.term.Unlock()
+	c := v.term.Cursor()
+	return c.X, c.Y, v.term.CursorVisible()
+}
+
+// Mode returns the terminal modes that the command has set, like
+// mouse reporting or application cursor keys.
+func (v *vterm) Mode() vt10x.ModeFlag {
+	v.term.Lock()
+	defer v.term.Unlock()
+	return v.term.Mode()
+}
+
+// Lines returns the screen as text, one string per row.
+func (v *vterm) Lines() []string {
+	v.term.Lock()
+	defer v.term.Unlock()
+	w, h := v.term.Size()
+	lines := make([]string, h)
+	for y := range lines {
+		var sb strings.Builder
+		for x := 0; x < w; x++ {
+			sb.WriteRune(v.term.Cell(x, y).Char)
+		}
+		lines[y] = sb.String()
+	}
+	return lines
+}
+
+// Find returns the position of the first occurrence of s on the
+// screen, or ok == false.
+func (v *vterm) Find(s string) (x, y int, ok bool) {
+	for y, line := range v.Lines() {
+		if i := strings.Index(line, s); i >= 0 {
+			return len([]rune(line[:i])), y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// WaitFor polls cond until it returns true or the timeout expires.
+func (v *vterm) WaitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) || v.Exited() {
+			return cond()
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Exited reports whether the command has terminated.
+func (v *vterm) Exited() bool {
+	select {
+	case <-v.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close kills the command if it still runs and releases the pseudo
+// terminal.
+func (v *vterm) Close() {
+	if !v.Exited() {
+		v.cmd.Process.Kill()
+		<-v.done
+	}
+	v.pty.Close()
+}